@@ -0,0 +1,297 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var defaultAsyncConfig = AsyncConfig{
+	Size:          1000,
+	FlushInterval: 30 * time.Second,
+	Overflow:      OverflowBlock,
+}
+
+// OverflowPolicy decides what happens to entries logged while the queue of
+// buffered writes is full.
+type OverflowPolicy int
+
+// OverflowPolicy.
+const (
+	// OverflowBlock blocks logging until there is room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop drops the new entries.
+	OverflowDrop
+	// OverflowDropDebugFirst drops the queued debug entries to make room,
+	// and the new entries if there are none.
+	OverflowDropDebugFirst
+)
+
+var overflowPolicyNames = map[OverflowPolicy]string{
+	OverflowBlock:          "block",
+	OverflowDrop:           "drop",
+	OverflowDropDebugFirst: "dropDebugFirst",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p OverflowPolicy) MarshalText() ([]byte, error) {
+	if name, ok := overflowPolicyNames[p]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown overflow policy: %d", int(p))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *OverflowPolicy) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = OverflowBlock
+		return nil
+	}
+	for k, v := range overflowPolicyNames {
+		if strings.EqualFold(v, string(text)) {
+			*p = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown overflow policy: %q", text)
+}
+
+// AsyncConfig enables buffered writes, the entries are encoded by the
+// logging goroutine and queued to be written to the sinks by a background
+// goroutine, so slow sinks don't block logging.
+type AsyncConfig struct {
+	// Size is the maximum number of queued entries.
+	Size int `json:"size" yaml:"size"`
+	// FlushInterval is the interval to sync the sinks at, zero disables
+	// periodic syncing.
+	FlushInterval time.Duration  `json:"flushInterval" yaml:"flushInterval"`
+	Overflow      OverflowPolicy `json:"overflow" yaml:"overflow"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The flush interval may be set
+// as a string parsed by time.ParseDuration, e.g. "30s", as well as in
+// nanoseconds.
+func (c *AsyncConfig) UnmarshalJSON(data []byte) error {
+	type plain AsyncConfig
+	v := struct {
+		*plain
+		FlushInterval json.RawMessage `json:"flushInterval"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return unmarshalJSONDuration(v.FlushInterval, &c.FlushInterval)
+}
+
+// asyncEntry is an encoded entry in the queue.
+type asyncEntry struct {
+	level zapcore.Level
+	p     []byte
+}
+
+// asyncWriter writes the queued entries to the sink in a background
+// goroutine.
+type asyncWriter struct {
+	ws     zapcore.WriteSyncer
+	errOut zapcore.WriteSyncer
+	cfg    AsyncConfig
+	stats  *loggerStats
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []asyncEntry
+	// inflight is set while the entries taken from queue are being written.
+	inflight bool
+	closed   bool
+	done     chan struct{}
+}
+
+func newAsyncWriter(ws, errOut zapcore.WriteSyncer, cfg AsyncConfig, stats *loggerStats) *asyncWriter {
+	if cfg.Size <= 0 {
+		cfg.Size = defaultAsyncConfig.Size
+	}
+	w := &asyncWriter{
+		ws:     ws,
+		errOut: errOut,
+		cfg:    cfg,
+		stats:  stats,
+		done:   make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	go w.run()
+	if cfg.FlushInterval > 0 {
+		go w.flush()
+	}
+	return w
+}
+
+// write queues the entry, the overflow policy decides what happens if the
+// queue is full.
+func (w *asyncWriter) write(level zapcore.Level, p []byte) error {
+	entry := asyncEntry{level: level, p: append([]byte(nil), p...)}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.queue) >= w.cfg.Size && !w.closed {
+		switch w.cfg.Overflow {
+		case OverflowDrop:
+			w.stats.addDropped(1)
+			return nil
+		case OverflowDropDebugFirst:
+			if !w.dropDebug() {
+				w.stats.addDropped(1)
+				return nil
+			}
+		default:
+			w.cond.Wait()
+		}
+	}
+	if w.closed {
+		return errSinkClosed
+	}
+
+	w.queue = append(w.queue, entry)
+	w.cond.Broadcast()
+	return nil
+}
+
+// dropDebug drops the oldest queued debug entry, it reports whether there
+// was one.
+func (w *asyncWriter) dropDebug() bool {
+	for i, e := range w.queue {
+		if e.level == zapcore.DebugLevel {
+			copy(w.queue[i:], w.queue[i+1:])
+			w.queue[len(w.queue)-1] = asyncEntry{}
+			w.queue = w.queue[:len(w.queue)-1]
+			w.stats.addDropped(1)
+			return true
+		}
+	}
+	return false
+}
+
+// run writes the queued entries until the writer is closed.
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch := w.queue
+		w.queue = nil
+		w.inflight = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		for _, e := range batch {
+			if _, err := w.ws.Write(e.p); err != nil {
+				// same as zap reports the errors of writing entries
+				fmt.Fprintf(w.errOut, "%v write error: %v\n", time.Now(), err)
+				w.errOut.Sync()
+			}
+		}
+
+		w.mu.Lock()
+		w.inflight = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// flush syncs the sink every flush interval until the writer is closed.
+func (w *asyncWriter) flush() {
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.ws.Sync()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Sync waits for the queued entries to be written, and syncs the sink.
+func (w *asyncWriter) Sync() error {
+	w.mu.Lock()
+	for (len(w.queue) > 0 || w.inflight) && !w.closed {
+		w.cond.Wait()
+	}
+	closed := w.closed
+	w.mu.Unlock()
+
+	if closed {
+		return nil
+	}
+	return w.ws.Sync()
+}
+
+// Close writes the queued entries and stops the background goroutines.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	return w.ws.Sync()
+}
+
+// asyncCore encodes entries like the cores of zapcore.NewCore, but queues
+// them to the asyncWriter instead of writing to the sink.
+type asyncCore struct {
+	zapcore.LevelEnabler
+
+	enc zapcore.Encoder
+	w   *asyncWriter
+}
+
+func (c *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &asyncCore{LevelEnabler: c.LevelEnabler, enc: enc, w: c.w}
+}
+
+func (c *asyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *asyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	err = c.w.write(ent.Level, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// Since we may be crashing the program, sync the output.
+		c.Sync()
+	}
+	return nil
+}
+
+func (c *asyncCore) Sync() error {
+	return c.w.Sync()
+}
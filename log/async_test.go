@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// blockingSyncer blocks writes until released, it notifies writes.
+type blockingSyncer struct {
+	writes  chan struct{}
+	release chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func newBlockingSyncer() *blockingSyncer {
+	return &blockingSyncer{
+		writes:  make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (s *blockingSyncer) Write(p []byte) (int, error) {
+	s.writes <- struct{}{}
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *blockingSyncer) Sync() error {
+	return nil
+}
+
+func (s *blockingSyncer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	fixtures := []struct {
+		overflow OverflowPolicy
+		expected string
+		dropped  uint64
+	}{
+		{OverflowDrop, "abc", 2},
+		{OverflowDropDebugFirst, "abd", 2},
+	}
+
+	for _, f := range fixtures {
+		ws := newBlockingSyncer()
+		stats := &loggerStats{}
+		w := newAsyncWriter(ws, zapcore.AddSync(ioutil.Discard), AsyncConfig{Size: 2, Overflow: f.overflow}, stats)
+
+		// the background goroutine blocks on writing "a"
+		w.write(zapcore.InfoLevel, []byte("a"))
+		<-ws.writes
+		w.write(zapcore.InfoLevel, []byte("b"))
+		w.write(zapcore.DebugLevel, []byte("c"))
+		w.write(zapcore.InfoLevel, []byte("d"))
+		w.write(zapcore.WarnLevel, []byte("e"))
+
+		close(ws.release)
+		assert.NoError(t, w.Sync())
+		assert.Equal(t, f.expected, ws.String(), "overflow %v", f.overflow)
+		assert.Equal(t, f.dropped, stats.snapshot().Dropped, "overflow %v", f.overflow)
+		assert.NoError(t, w.Close())
+	}
+}
+
+func TestConfigOpenAsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "async")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.log")
+	c, err := ParseConfigFromURIString("logger:logfmt?buffered=true&timeKey=-&outputPath=" + path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("a")
+	l.Sync()
+	b, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "level=info msg=a\n", string(b))
+
+	l.Info("b")
+	assert.NoError(t, l.Close())
+	b, _ = ioutil.ReadFile(path)
+	assert.Equal(t, 2, strings.Count(string(b), "\n"))
+}
@@ -1,10 +1,14 @@
 package log
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/imperfectgo/zap-syslog"
 	"github.com/imperfectgo/zap-syslog/syslog"
@@ -19,6 +23,8 @@ var (
 		DisableCaller:           true,
 		Lumberjacks:             nil,
 		ErrorLumberjacks:        nil,
+		Facility:                defaultSyslogEncoderConfig.Facility,
+		Buffer:                  defaultBufferConfig,
 		defaultOutputPaths:      []string{"stderr"},
 		defaultErrorOutputPaths: []string{"stderr"},
 	}
@@ -34,8 +40,121 @@ const (
 	ConsoleEncoder
 	// SyslogEncoder represents a Syslog (RFC5425) encoder type.
 	SyslogEncoder
+	// LogfmtEncoder represents a logfmt encoder type, which writes entries as key=value pairs.
+	LogfmtEncoder
+	// GELFEncoder represents a GELF 1.1 encoder type, which sends entries to Graylog.
+	GELFEncoder
+	// JournaldEncoder represents a systemd-journald encoder type, which speaks the native journal protocol.
+	JournaldEncoder
 )
 
+var encoderTypeNames = map[EncoderType]string{
+	JSONEncoder:     "json",
+	ConsoleEncoder:  "console",
+	SyslogEncoder:   "syslog",
+	LogfmtEncoder:   "logfmt",
+	GELFEncoder:     "gelf",
+	JournaldEncoder: "journald",
+}
+
+// String returns the name of the encoder type, as used in logger URIs.
+func (t EncoderType) String() string {
+	if name, ok := encoderTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EncoderType(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t EncoderType) MarshalText() ([]byte, error) {
+	if name, ok := encoderTypeNames[t]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown encoder type: %d", int(t))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *EncoderType) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	for k, v := range encoderTypeNames {
+		if v == name {
+			*t = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown encoder type: %q", text)
+}
+
+// framingName returns the name of the framing in logger URIs and config
+// files.
+func framingName(f zapsyslog.Framing) (string, error) {
+	switch f {
+	case zapsyslog.NonTransparentFraming:
+		return "non-transparent", nil
+	case zapsyslog.OctetCountingFraming:
+		return "octet-counting", nil
+	}
+	return "", fmt.Errorf("unknown framing: %d", int(f))
+}
+
+// parseFraming parses the name of a framing.
+func parseFraming(s string) (zapsyslog.Framing, error) {
+	v := strings.ToLower(s)
+	switch v {
+	case "", "0", "non-transparent", "non-transparent-framing":
+		return zapsyslog.NonTransparentFraming, nil
+	case "1", "octet-counting", "octet-counting-framing":
+		return zapsyslog.OctetCountingFraming, nil
+	}
+	return 0, fmt.Errorf("unknown framing: %s", v)
+}
+
+// SyslogFormat is the format of syslog messages.
+type SyslogFormat int
+
+// SyslogFormat.
+const (
+	// RFC5424Format is the format of RFC5424.
+	RFC5424Format SyslogFormat = iota
+	// RFC3164Format is the legacy BSD format of RFC3164,
+	// "<PRI>Mmm dd hh:mm:ss host tag[pid]: msg".
+	RFC3164Format
+)
+
+// MarshalText implements encoding.TextMarshaler.
+func (f SyslogFormat) MarshalText() ([]byte, error) {
+	switch f {
+	case RFC5424Format:
+		return []byte("rfc5424"), nil
+	case RFC3164Format:
+		return []byte("rfc3164"), nil
+	}
+	return nil, fmt.Errorf("unknown syslog format: %d", int(f))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *SyslogFormat) UnmarshalText(text []byte) error {
+	v := strings.ToLower(string(text))
+	switch v {
+	case "", "rfc5424":
+		*f = RFC5424Format
+	case "rfc3164", "bsd":
+		*f = RFC3164Format
+	default:
+		return fmt.Errorf("unknown syslog format: %s", v)
+	}
+	return nil
+}
+
+// facilityName returns the name of the facility in logger URIs and config
+// files.
+func facilityName(f syslog.Priority) (string, error) {
+	if name, ok := facilityNames[f]; ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown facility: %d", int(f))
+}
+
 // Config offers a declarative way to construct a logger. It doesn't do
 // anything that can't be done with New, Options, and the various
 // zapcore.WriteSyncer and zapcore.Core wrappers, but it's a simpler way to
@@ -46,6 +165,9 @@ type Config struct {
 	// level, so calling Config.Level.SetLevel will atomically change the log
 	// level of all loggers descended from this config.
 	Level zap.AtomicLevel `json:"level" yaml:"level"`
+	// Levels are the levels of named loggers, which override Level for the
+	// loggers with the names and their children, e.g. {"db": "debug"}.
+	Levels NameLevels `json:"levels,omitempty" yaml:"levels,omitempty"`
 	// Development puts the logger in development mode, which changes the
 	// behavior of DPanicLevel and takes stacktraces more liberally.
 	Development bool `json:"development" yaml:"development"`
@@ -55,142 +177,333 @@ type Config struct {
 	// DisableStacktrace completely disables automatic stacktrace capturing. By
 	// default, stacktraces are captured for WarnLevel and above logs in
 	// development and ErrorLevel and above in production.
-	DisableStacktrace bool     `json:"disableStacktrace" yaml:"disableStacktrace"`
-	OutputPaths       []string `json:"outputPaths" yaml:"outputPaths"`
+	DisableStacktrace bool `json:"disableStacktrace" yaml:"disableStacktrace"`
+	// OutputPaths is a list of paths to write logs to. The default is
+	// standard error, except for syslog, which only writes to the paths if
+	// set, along with the output addresses.
+	OutputPaths []string `json:"outputPaths" yaml:"outputPaths"`
 	// ErrorOutputPaths is a list of paths to write internal logger errors to.
 	// The default is standard error.
 	//
-	// Note that this setting only affects internal errors; for sample code that
-	// sends error-level logs to a different location from info- and debug-level
-	// logs, see the package-level AdvancedConfiguration example.
-	ErrorOutputPaths []string           `json:"errorOutputPaths" yaml:"errorOutputPaths"`
-	Lumberjacks      []LumberjackConfig `json:"lumberjacks" json:"lumberjacks"`
-	ErrorLumberjacks []LumberjackConfig `json:"errorLumberjacks" json:"errorLumberjacks"`
+	// Note that this setting only affects internal errors; to send error-level
+	// logs to a different location from info- and debug-level logs, use
+	// MinLevel and MaxLevel, or the level range of a lumberjack.
+	ErrorOutputPaths []string `json:"errorOutputPaths" yaml:"errorOutputPaths"`
+	// ReopenOnSignal is the name of a signal, e.g. "SIGHUP", which reopens
+	// the files of OutputPaths and ErrorOutputPaths, after they are rotated
+	// by logrotate. See Logger.Reopen.
+	ReopenOnSignal string `json:"reopenOnSignal,omitempty" yaml:"reopenOnSignal,omitempty"`
+	// Lumberjacks is a list of rolling files to write logs to. A lumberjack
+	// with its own level range only receives the entries within that range,
+	// regardless of MinLevel and MaxLevel.
+	Lumberjacks []LumberjackConfig `json:"lumberjacks" yaml:"lumberjacks"`
+	// ErrorLumberjacks is a list of rolling files to write internal logger
+	// errors to, just like ErrorOutputPaths.
+	ErrorLumberjacks []LumberjackConfig `json:"errorLumberjacks" yaml:"errorLumberjacks"`
+	// MinLevel and MaxLevel limit the entries written to the target to the
+	// given level range, on top of Level. Either one can be omitted.
+	MinLevel *zapcore.Level `json:"minLevel,omitempty" yaml:"minLevel,omitempty"`
+	MaxLevel *zapcore.Level `json:"maxLevel,omitempty" yaml:"maxLevel,omitempty"`
+	// Sampling enables sampling of the entries written to the target, which
+	// caps the CPU and I/O load of logging. Sampling is disabled if nil.
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Async enables buffered writes, so slow sinks don't block logging. The
+	// writes are synchronous if nil.
+	Async *AsyncConfig `json:"async,omitempty" yaml:"async,omitempty"`
+	// EncoderConfig customizes the keys and the primitive encoders of the
+	// json, console and logfmt encoders, and the JSON encoded MSG of syslog
+	// messages.
+	EncoderConfig EncoderConfig `json:"encoderConfig" yaml:"encoderConfig"`
 
 	OutputAddresses []string `json:"outputAddresses" yaml:"outputAddresses"`
 	// Syslog related config
+	Format   SyslogFormat      `json:"format" yaml:"format"`
 	Framing  zapsyslog.Framing `json:"framing" yaml:"framing"`
 	Facility syslog.Priority   `json:"facility" yaml:"facility"`
 	Hostname string            `json:"hostname" yaml:"hostname"`
 	PID      int               `json:"pid" yaml:"pid"`
 	App      string            `json:"app" yaml:"app"`
+	// MsgIDKey is the key of the field used as the MSGID of syslog messages,
+	// the logger name is used if unset or absent.
+	MsgIDKey string `json:"msgIDKey" yaml:"msgIDKey"`
+	// TLS configures the output addresses with the "tls" network, e.g.
+	// "tls:logs.example.com:6514". Octet-counting framing is always used
+	// on TLS connections, as required by RFC5425, whatever Framing is.
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+	// Buffer configures the buffer of syslog and GELF outputs, which holds
+	// the messages while they are disconnected.
+	Buffer BufferConfig `json:"buffer" yaml:"buffer"`
+
+	// GELF related config, Hostname and App are shared with syslog.
+	// Compression only applies to UDP.
+	Compression GELFCompression `json:"compression" yaml:"compression"`
 
 	defaultOutputPaths      []string
 	defaultErrorOutputPaths []string
 }
 
 // Build builds options into zap.Logger.
+//
+// The sinks opened by Build are never released, use Open if the logger is
+// going to be replaced at runtime.
 func (cfg Config) Build(opts ...zap.Option) (*zap.Logger, error) {
+	l, err := cfg.Open(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Logger, nil
+}
+
+// Open builds options into a Logger, which owns every sink opened during the
+// build and releases them on Close. Sinks opened so far are closed if the
+// build fails.
+func (cfg Config) Open(opts ...zap.Option) (*Logger, error) {
+	return Configs{cfg}.Open(opts...)
+}
+
+// openCore builds the encoder and opens the sinks of the config, it returns
+// the core writing to the sinks, along with the sink for internal errors.
+// The cores writing to the sinks directly are wrapped by wrap, if given.
+func (cfg Config) openCore(b *builder, wrap func(zapcore.Core) zapcore.Core) (zapcore.Core, zapcore.WriteSyncer, error) {
+	closers := &b.closers
+
+	if cfg.EncoderConfig.Schema != "" && cfg.EncoderType != JSONEncoder {
+		return nil, nil, errors.New("config: schema is only supported by the json encoder")
+	}
+
 	var enc zapcore.Encoder
 	switch cfg.EncoderType {
 	case JSONEncoder:
-		enc = zapcore.NewJSONEncoder(defaultJSONEncoderConfig)
+		encoderCfg, err := cfg.EncoderConfig.apply(defaultJSONEncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.EncoderConfig.Schema != "" {
+			enc, err = newSchemaEncoder(cfg.EncoderConfig.Schema, encoderCfg)
+			if err != nil {
+				return nil, nil, err
+			}
+		} else {
+			enc = newJSONEncoder(encoderCfg)
+		}
+		enc = cfg.EncoderConfig.wrapEncoder(enc)
 	case ConsoleEncoder:
-		enc = zapcore.NewConsoleEncoder(defaultConsoleEncoderConfig)
+		encoderCfg, err := cfg.EncoderConfig.apply(defaultConsoleEncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc = cfg.EncoderConfig.wrapEncoder(newConsoleEncoder(encoderCfg))
+	case LogfmtEncoder:
+		encoderCfg, err := cfg.EncoderConfig.apply(defaultLogfmtEncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc = cfg.EncoderConfig.wrapEncoder(NewLogfmtEncoder(encoderCfg))
 	case SyslogEncoder:
 		encoderCfg := defaultSyslogEncoderConfig
+		var err error
+		encoderCfg.EncoderConfig, err = cfg.EncoderConfig.apply(encoderCfg.EncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoderCfg.Format = cfg.Format
 		encoderCfg.Framing = cfg.Framing
 		encoderCfg.Facility = cfg.Facility
 		encoderCfg.Hostname = cfg.Hostname
 		encoderCfg.PID = cfg.PID
 		encoderCfg.App = cfg.App
-		enc = zapsyslog.NewSyslogEncoder(defaultSyslogEncoderConfig)
+		encoderCfg.MsgIDKey = cfg.MsgIDKey
+		enc = cfg.EncoderConfig.wrapEncoder(NewSyslogEncoder(encoderCfg))
+	case GELFEncoder:
+		encoderCfg := defaultGELFEncoderConfig
+		var err error
+		encoderCfg.EncoderConfig, err = cfg.EncoderConfig.apply(encoderCfg.EncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoderCfg.Hostname = cfg.Hostname
+		encoderCfg.App = cfg.App
+		enc = cfg.EncoderConfig.wrapEncoder(NewGELFEncoder(encoderCfg))
+	case JournaldEncoder:
+		encoderCfg := defaultJournaldEncoderConfig
+		var err error
+		encoderCfg.EncoderConfig, err = cfg.EncoderConfig.apply(encoderCfg.EncoderConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoderCfg.App = cfg.App
+		enc = cfg.EncoderConfig.wrapEncoder(NewJournaldEncoder(encoderCfg))
 	default:
-		return nil, fmt.Errorf("unknown encoder type: %d", int(cfg.EncoderType))
+		return nil, nil, fmt.Errorf("unknown encoder type: %d", int(cfg.EncoderType))
 	}
 
 	var err error
 	var sink zapcore.WriteSyncer
 	var errSink zapcore.WriteSyncer
+	// cores for the sinks with their own level range
+	var rangedCores []zapcore.Core
 
 	switch cfg.EncoderType {
-	case JSONEncoder, ConsoleEncoder:
-		sink, errSink, err = cfg.openStandardSinks()
+	case JSONEncoder, ConsoleEncoder, LogfmtEncoder:
+		sink, errSink, err = cfg.openStandardSinks(b)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-
-		lumberSink, errLumberSink := cfg.openLumberjackSinks()
-		sink = zapcore.NewMultiWriteSyncer(sink, lumberSink)
-		errSink = zapcore.NewMultiWriteSyncer(errSink, errLumberSink)
-
 	case SyslogEncoder:
-		sink, errSink, err = cfg.openSyslogSinks()
+		sink, errSink, err = cfg.openSyslogSinks(b)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+	case GELFEncoder:
+		sink, errSink, err = cfg.openGELFSinks(b)
+		if err != nil {
+			return nil, nil, err
+		}
+	case JournaldEncoder:
+		sink, errSink, err = cfg.openJournaldSinks(b)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	errLumberSink := openLumberjack(closers, cfg.ErrorLumberjacks...)
+	errSink = zapcore.NewMultiWriteSyncer(errSink, errLumberSink)
+
+	// the levels of named loggers are checked by nameLevelCore, the cores
+	// only rule out the levels no logger enables
+	localLevels := newNameLevelRules(cfg.Levels)
+	levels := &nameLevelEnabler{base: cfg.Level, local: localLevels}
+
+	newCore := func(enc zapcore.Encoder, ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+		var core zapcore.Core
+		if cfg.Async != nil {
+			w := newAsyncWriter(ws, errSink, *cfg.Async, b.stats)
+			closers.add(w.Close)
+			core = &asyncCore{LevelEnabler: enab, enc: enc, w: w}
+		} else {
+			core = zapcore.NewCore(enc, ws, enab)
+		}
+		if wrap != nil {
+			core = wrap(core)
 		}
+		return core
 	}
 
-	cfgOpts := cfg.buildOptions(errSink)
-	zapOpts := make([]zap.Option, 0, len(cfgOpts)+len(opts))
-	zapOpts = append(zapOpts, cfgOpts...)
-	zapOpts = append(zapOpts, opts...)
+	// lumberjacks are written to along with the sinks
+	switch cfg.EncoderType {
+	case JSONEncoder, ConsoleEncoder, LogfmtEncoder, SyslogEncoder:
+		for _, lc := range cfg.Lumberjacks {
+			lumberSink := openLumberjack(closers, lc)
+			if lc.MinLevel == nil && lc.MaxLevel == nil {
+				sink = zapcore.NewMultiWriteSyncer(sink, lumberSink)
+				continue
+			}
+
+			enab := newLevelRange(levels, lc.MinLevel, lc.MaxLevel)
+			rangedCores = append(rangedCores, newCore(enc.Clone(), lumberSink, enab))
+		}
+	}
 
-	l := zap.New(
-		zapcore.NewCore(enc, sink, cfg.Level),
-		zapOpts...,
-	)
-	return l, nil
+	enab := newLevelRange(levels, cfg.MinLevel, cfg.MaxLevel)
+	cores := append([]zapcore.Core{newCore(enc, sink, enab)}, rangedCores...)
+	core := zapcore.NewTee(cores...)
+	if cfg.Sampling != nil {
+		core = cfg.Sampling.wrapCore(core, b.stats)
+	}
+	core = &nameLevelCore{Core: core, base: cfg.Level, local: localLevels}
+	return core, errSink, nil
 }
 
-func (cfg Config) openStandardSinks() (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
-	var outputPaths = cfg.OutputPaths
-	var errorOutputPaths = cfg.ErrorOutputPaths
-	if outputPaths == nil {
-		outputPaths = cfg.defaultOutputPaths
+// stackLevel returns the minimum level to capture stacktraces at.
+func (cfg Config) stackLevel() zapcore.Level {
+	switch {
+	case cfg.DisableStacktrace:
+		return zapcore.FatalLevel + 1
+	case cfg.Development:
+		return zap.WarnLevel
+	default:
+		return zap.ErrorLevel
 	}
+}
+
+// openErrorSink opens the error output paths, or the default ones if unset.
+func (cfg Config) openErrorSink(b *builder) (zapcore.WriteSyncer, error) {
+	var errorOutputPaths = cfg.ErrorOutputPaths
 	if errorOutputPaths == nil {
 		errorOutputPaths = cfg.defaultErrorOutputPaths
 	}
+	return b.openPaths(errorOutputPaths...)
+}
 
-	sink, closeOut, err := zap.Open(outputPaths...)
+func (cfg Config) openStandardSinks(b *builder) (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
+	var outputPaths = cfg.OutputPaths
+	if outputPaths == nil {
+		outputPaths = cfg.defaultOutputPaths
+	}
+
+	sink, err := b.openPaths(outputPaths...)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	errSink, _, err := zap.Open(errorOutputPaths...)
+	errSink, err := cfg.openErrorSink(b)
 	if err != nil {
-		closeOut()
 		return nil, nil, err
 	}
 
 	return sink, errSink, nil
 }
 
-func (cfg Config) openLumberjackSinks() (zapcore.WriteSyncer, zapcore.WriteSyncer) {
-	sink := openLumberjack(cfg.Lumberjacks...)
-	errSink := openLumberjack(cfg.ErrorLumberjacks...)
-	return sink, errSink
-}
-
-func (cfg Config) openSyslogSinks() (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
-	if len(cfg.OutputAddresses) == 0 {
-		return nil, nil, fmt.Errorf("")
+// openSyslogSinks opens the output addresses, along with the output paths if
+// set. The local syslog socket is used if there is no output at all.
+func (cfg Config) openSyslogSinks(b *builder) (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
+	addrs := cfg.OutputAddresses
+	if len(addrs) == 0 && len(cfg.OutputPaths) == 0 && len(cfg.Lumberjacks) == 0 {
+		addr, err := findLocalSyslog()
+		if err != nil {
+			return nil, nil, err
+		}
+		addrs = []string{addr}
 	}
 
-	errSink, closeErr, err := zap.Open("stderr")
+	errSink, err := cfg.openErrorSink(b)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	writeSyncers := make([]zapcore.WriteSyncer, 0, len(cfg.OutputAddresses))
-	for _, addr := range cfg.OutputAddresses {
-		networkAddr := strings.SplitN(addr, ":", 2)
-
-		var address string
-		network := "tcp"
-		if len(networkAddr) == 1 {
-			address = networkAddr[0]
-		} else {
-			network, address = networkAddr[0], networkAddr[1]
+	var tlsConfig *tls.Config
+	if cfg.usesTLS() {
+		if tlsConfig, err = cfg.TLS.build(); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		s, err := zapsyslog.NewConnSyncer(network, address)
+	writeSyncers := make([]zapcore.WriteSyncer, 0, len(addrs)+1)
+	if len(cfg.OutputPaths) > 0 {
+		s, err := b.openPaths(cfg.OutputPaths...)
 		if err != nil {
-			closeErr()
 			return nil, nil, err
 		}
+		writeSyncers = append(writeSyncers, s)
+	}
+	for _, addr := range addrs {
+		network, address := splitOutputAddress(addr, "tcp")
+		if network != "tls" {
+			s := newConnSyncer(network, address, cfg.Buffer, b.stats)
+			b.closers.add(s.Close)
+			writeSyncers = append(writeSyncers, s)
+			continue
+		}
 
+		// only the TLS connections are reframed, the other outputs keep the
+		// framing of the config
+		conn := newTLSConnSyncer(address, tlsConfig, cfg.Buffer, b.stats)
+		var s zapcore.WriteSyncer = conn
+		if cfg.Framing != zapsyslog.OctetCountingFraming {
+			s = &octetCountedSyncer{conn: conn}
+		}
+		b.closers.add(conn.Close)
 		writeSyncers = append(writeSyncers, s)
 	}
 
@@ -198,26 +511,79 @@ func (cfg Config) openSyslogSinks() (zapcore.WriteSyncer, zapcore.WriteSyncer, e
 	return sink, errSink, nil
 }
 
-func (cfg Config) buildOptions(errSink zapcore.WriteSyncer) []zap.Option {
-	opts := []zap.Option{zap.ErrorOutput(errSink)}
+func (cfg Config) openGELFSinks(b *builder) (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
+	if len(cfg.OutputAddresses) == 0 {
+		return nil, nil, errors.New("config: no output address for gelf")
+	}
 
-	if cfg.Development {
-		opts = append(opts, zap.Development())
+	errSink, err := cfg.openErrorSink(b)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if !cfg.DisableCaller {
-		opts = append(opts, zap.AddCaller())
+	writeSyncers := make([]zapcore.WriteSyncer, 0, len(cfg.OutputAddresses))
+	for _, addr := range cfg.OutputAddresses {
+		network, address := splitOutputAddress(addr, "udp")
+		switch network {
+		case "udp", "udp4", "udp6":
+			s := newGELFUDPSyncer(network, address, cfg.Compression, cfg.Buffer, b.stats)
+			b.closers.add(s.Close)
+			writeSyncers = append(writeSyncers, s)
+		case "tcp", "tcp4", "tcp6":
+			s := &nullFramedSyncer{conn: newConnSyncer(network, address, cfg.Buffer, b.stats)}
+			b.closers.add(s.Close)
+			writeSyncers = append(writeSyncers, s)
+		default:
+			return nil, nil, fmt.Errorf("config: unsupported network for gelf: %s", network)
+		}
 	}
 
-	stackLevel := zap.ErrorLevel
-	if cfg.Development {
-		stackLevel = zap.WarnLevel
+	sink := zapcore.NewMultiWriteSyncer(writeSyncers...)
+	return sink, errSink, nil
+}
+
+func (cfg Config) openJournaldSinks(b *builder) (zapcore.WriteSyncer, zapcore.WriteSyncer, error) {
+	errSink, err := cfg.openErrorSink(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths := cfg.OutputAddresses
+	if len(paths) == 0 {
+		paths = []string{defaultJournaldSocket}
+	}
+	writeSyncers := make([]zapcore.WriteSyncer, 0, len(paths))
+	for _, path := range paths {
+		s, err := newJournaldSyncer(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		b.closers.add(s.Close)
+		writeSyncers = append(writeSyncers, s)
 	}
-	if !cfg.DisableStacktrace {
-		opts = append(opts, zap.AddStacktrace(stackLevel))
+
+	sink := zapcore.NewMultiWriteSyncer(writeSyncers...)
+	return sink, errSink, nil
+}
+
+// usesTLS reports whether any output address has the "tls" network.
+func (cfg Config) usesTLS() bool {
+	for _, addr := range cfg.OutputAddresses {
+		if network, _ := splitOutputAddress(addr, "tcp"); network == "tls" {
+			return true
+		}
 	}
+	return false
+}
 
-	return opts
+// splitOutputAddress splits an output address in the form of
+// "[network:]address" into its network and address.
+func splitOutputAddress(addr string, defaultNetwork string) (string, string) {
+	networkAddr := strings.SplitN(addr, ":", 2)
+	if len(networkAddr) == 1 {
+		return defaultNetwork, networkAddr[0]
+	}
+	return networkAddr[0], networkAddr[1]
 }
 
 func appendStringsFromStrings(output []string, vs []string) []string {
@@ -253,36 +619,161 @@ func (cfg *Config) populateCommonFromQS(values url.Values) error {
 				return errors.WithMessage(err, "config: error parsing disableStacktrace")
 			}
 			cfg.DisableStacktrace = disableStacktrace
+		case "level":
+			level, err := parseLevel(vs[0])
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing level")
+			}
+			cfg.Level = zap.NewAtomicLevelAt(*level)
+		case "minLevel":
+			level, err := parseLevel(vs[0])
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing minLevel")
+			}
+			cfg.MinLevel = level
+		case "maxLevel":
+			level, err := parseLevel(vs[0])
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing maxLevel")
+			}
+			cfg.MaxLevel = level
+		case "levels":
+			levels, err := parseNameLevels(strings.Join(vs, ","))
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing levels")
+			}
+			cfg.Levels = levels
+		case "reopenOnSignal":
+			if _, err := parseSignal(vs[0]); err != nil {
+				return errors.WithMessage(err, "config: error parsing reopenOnSignal")
+			}
+			cfg.ReopenOnSignal = vs[0]
+		case "sampling.initial", "sampling.thereafter", "sampling.tick":
+			if err := cfg.populateSamplingFromQS(k, vs[0]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return cfg.populateAsyncFromQS(values)
+}
+
+// populateAsyncFromQS enables buffered writes if "buffered" is true, along
+// with the other settings of them.
+func (cfg *Config) populateAsyncFromQS(values url.Values) error {
+	v := values.Get("buffered")
+	if v == "" {
+		return nil
+	}
+	buffered, err := strconv.ParseBool(v)
+	if err != nil {
+		return errors.WithMessage(err, "config: error parsing buffered")
+	}
+	if !buffered {
+		cfg.Async = nil
+		return nil
+	}
+
+	async := defaultAsyncConfig
+	if v := values.Get("bufferSize"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing bufferSize")
+		}
+		async.Size = size
+	}
+	if v := values.Get("flushInterval"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing flushInterval")
 		}
+		async.FlushInterval = interval
+	}
+	if err := async.Overflow.UnmarshalText([]byte(values.Get("overflow"))); err != nil {
+		return errors.WithMessage(err, "config: error parsing overflow")
+	}
+	cfg.Async = &async
+	return nil
+}
+
+func (cfg *Config) populateSamplingFromQS(k, v string) error {
+	if cfg.Sampling == nil {
+		sampling := defaultSamplingConfig
+		cfg.Sampling = &sampling
 	}
 
+	switch k {
+	case "sampling.initial":
+		initial, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing sampling.initial")
+		}
+		cfg.Sampling.Initial = initial
+	case "sampling.thereafter":
+		thereafter, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing sampling.thereafter")
+		}
+		cfg.Sampling.Thereafter = thereafter
+	case "sampling.tick":
+		tick, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing sampling.tick")
+		}
+		cfg.Sampling.Tick = tick
+	}
 	return nil
 }
 
 func (cfg *Config) populateStandardEncoderFromQS(values url.Values) error {
 	var outputPaths []string
-	var errorOutputPaths []string
 
 	for k, vs := range values {
 		if len(vs) == 0 {
 			continue
 		}
 
+		known, err := cfg.EncoderConfig.set(k, vs[0])
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing "+k)
+		}
+		if known {
+			continue
+		}
+
 		switch k {
 		case "outputPath":
 			outputPaths = appendStringsFromStrings(outputPaths, vs)
 		case "outputPaths":
 			outputPaths = appendStringsFromCommaSeparatedStrings(outputPaths, vs)
-		case "errorOutputPath":
-			errorOutputPaths = appendStringsFromStrings(errorOutputPaths, vs)
-		case "errorOutputPaths":
-			errorOutputPaths = appendStringsFromCommaSeparatedStrings(errorOutputPaths, vs)
 		case "lumberjack":
 			lumberjacks, err := ParseLumberjacks(vs...)
 			if err != nil {
 				return err
 			}
 			cfg.Lumberjacks = lumberjacks
+		}
+	}
+
+	cfg.OutputPaths = outputPaths
+	return cfg.populateErrorOutputsFromQS(values)
+}
+
+// populateErrorOutputsFromQS populates the error output paths and
+// lumberjacks, which are supported by all the encoder types.
+func (cfg *Config) populateErrorOutputsFromQS(values url.Values) error {
+	var errorOutputPaths []string
+
+	for k, vs := range values {
+		if len(vs) == 0 {
+			continue
+		}
+
+		switch k {
+		case "errorOutputPath":
+			errorOutputPaths = appendStringsFromStrings(errorOutputPaths, vs)
+		case "errorOutputPaths":
+			errorOutputPaths = appendStringsFromCommaSeparatedStrings(errorOutputPaths, vs)
 		case "errorLumberjack":
 			lumberjacks, err := ParseLumberjacks(vs...)
 			if err != nil {
@@ -292,14 +783,17 @@ func (cfg *Config) populateStandardEncoderFromQS(values url.Values) error {
 		}
 	}
 
-	cfg.OutputPaths = outputPaths
 	cfg.ErrorOutputPaths = errorOutputPaths
 	return nil
 }
 
 func (cfg *Config) populateSyslogEncoderFromQS(values url.Values) error {
-	var outputAddresses []string
+	// the output paths are written to along with the output addresses
+	if err := cfg.populateStandardEncoderFromQS(values); err != nil {
+		return err
+	}
 
+	var outputAddresses []string
 	for k, vs := range values {
 		if len(vs) == 0 {
 			continue
@@ -310,16 +804,16 @@ func (cfg *Config) populateSyslogEncoderFromQS(values url.Values) error {
 			outputAddresses = appendStringsFromStrings(outputAddresses, vs)
 		case "outputAddresses":
 			outputAddresses = appendStringsFromCommaSeparatedStrings(outputAddresses, vs)
+		case "format":
+			if err := cfg.Format.UnmarshalText([]byte(vs[0])); err != nil {
+				return errors.WithMessage(err, "config: error parsing format")
+			}
 		case "framing":
-			v := strings.ToLower(vs[0])
-			switch v {
-			case "", "0", "non-transparent", "non-transparent-framing":
-				cfg.Framing = zapsyslog.NonTransparentFraming
-			case "1", "octet-counting", "octet-counting-framing":
-				cfg.Framing = zapsyslog.OctetCountingFraming
-			default:
-				return fmt.Errorf("config: unknown framing: %s", v)
+			framing, err := parseFraming(vs[0])
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing framing")
 			}
+			cfg.Framing = framing
 		case "facility":
 			facility, err := syslog.FacilityPriority(vs[0])
 			if err != nil {
@@ -336,6 +830,15 @@ func (cfg *Config) populateSyslogEncoderFromQS(values url.Values) error {
 			cfg.PID = pid
 		case "app":
 			cfg.App = vs[0]
+		case "msgIDKey":
+			cfg.MsgIDKey = vs[0]
+		default:
+			if _, err := cfg.TLS.set(k, vs[0]); err != nil {
+				return errors.WithMessage(err, "config: error parsing "+k)
+			}
+			if _, err := cfg.Buffer.set(k, vs[0]); err != nil {
+				return errors.WithMessage(err, "config: error parsing "+k)
+			}
 		}
 	}
 
@@ -343,6 +846,76 @@ func (cfg *Config) populateSyslogEncoderFromQS(values url.Values) error {
 	return nil
 }
 
+func (cfg *Config) populateGELFEncoderFromQS(values url.Values) error {
+	var outputAddresses []string
+
+	for k, vs := range values {
+		if len(vs) == 0 {
+			continue
+		}
+
+		known, err := cfg.EncoderConfig.set(k, vs[0])
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing "+k)
+		}
+		if known {
+			continue
+		}
+
+		switch k {
+		case "outputAddress":
+			outputAddresses = appendStringsFromStrings(outputAddresses, vs)
+		case "outputAddresses":
+			outputAddresses = appendStringsFromCommaSeparatedStrings(outputAddresses, vs)
+		case "compression":
+			if err := cfg.Compression.UnmarshalText([]byte(vs[0])); err != nil {
+				return errors.WithMessage(err, "config: error parsing compression")
+			}
+		case "hostname":
+			cfg.Hostname = vs[0]
+		case "app":
+			cfg.App = vs[0]
+		default:
+			if _, err := cfg.Buffer.set(k, vs[0]); err != nil {
+				return errors.WithMessage(err, "config: error parsing "+k)
+			}
+		}
+	}
+
+	cfg.OutputAddresses = outputAddresses
+	return cfg.populateErrorOutputsFromQS(values)
+}
+
+func (cfg *Config) populateJournaldEncoderFromQS(values url.Values) error {
+	var outputAddresses []string
+
+	for k, vs := range values {
+		if len(vs) == 0 {
+			continue
+		}
+
+		known, err := cfg.EncoderConfig.set(k, vs[0])
+		if err != nil {
+			return errors.WithMessage(err, "config: error parsing "+k)
+		}
+		if known {
+			continue
+		}
+
+		switch k {
+		case "outputAddress":
+			outputAddresses = appendStringsFromStrings(outputAddresses, vs)
+		case "outputAddresses":
+			outputAddresses = appendStringsFromCommaSeparatedStrings(outputAddresses, vs)
+		case "app":
+			cfg.App = vs[0]
+		}
+	}
+
+	cfg.OutputAddresses = outputAddresses
+	return cfg.populateErrorOutputsFromQS(values)
+}
+
 // ParseConfigFromURI parses config from a config uri.
 func ParseConfigFromURI(u *url.URL) (*Config, error) {
 	if u.Scheme != "logger" {
@@ -369,12 +942,30 @@ func ParseConfigFromURI(u *url.URL) (*Config, error) {
 		if err != nil {
 			return nil, err
 		}
+	case "logfmt":
+		config.EncoderType = LogfmtEncoder
+		err := config.populateStandardEncoderFromQS(values)
+		if err != nil {
+			return nil, err
+		}
 	case "syslog":
 		config.EncoderType = SyslogEncoder
 		err := config.populateSyslogEncoderFromQS(values)
 		if err != nil {
 			return nil, err
 		}
+	case "gelf":
+		config.EncoderType = GELFEncoder
+		err := config.populateGELFEncoderFromQS(values)
+		if err != nil {
+			return nil, err
+		}
+	case "journald":
+		config.EncoderType = JournaldEncoder
+		err := config.populateJournaldEncoderFromQS(values)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("unsupported logger %q", u.Opaque)
 	}
@@ -391,3 +982,208 @@ func ParseConfigFromURIString(uri string) (*Config, error) {
 
 	return ParseConfigFromURI(u)
 }
+
+// addStandardValues adds the output paths, lumberjacks and encoder config
+// to the values of logger URIs.
+func (cfg Config) addStandardValues(values url.Values) {
+	values["outputPath"] = cfg.OutputPaths
+	for _, l := range cfg.Lumberjacks {
+		values.Add("lumberjack", l.String())
+	}
+	cfg.addErrorValues(values)
+	for k, v := range cfg.EncoderConfig.values() {
+		values.Set(k, v)
+	}
+}
+
+// addErrorValues adds the error output paths and lumberjacks to the values
+// of logger URIs.
+func (cfg Config) addErrorValues(values url.Values) {
+	values["errorOutputPath"] = cfg.ErrorOutputPaths
+	for _, l := range cfg.ErrorLumberjacks {
+		values.Add("errorLumberjack", l.String())
+	}
+}
+
+// URI returns the canonical logger URI of the config, which parses back into
+// an equivalent config with ParseConfigFromURI. Parameters are sorted by
+// name, and every setting applicable to the encoder type is included.
+//
+// The level is only included if the config has a level of its own, e.g. one
+// parsed from a URI with a level, so the configs sharing the level controlled
+// by the log.level flag parse back into configs sharing it too.
+func (cfg Config) URI() (*url.URL, error) {
+	opaque, err := cfg.EncoderType.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("development", strconv.FormatBool(cfg.Development))
+	values.Set("disableCaller", strconv.FormatBool(cfg.DisableCaller))
+	values.Set("disableStacktrace", strconv.FormatBool(cfg.DisableStacktrace))
+	if cfg.Level != (zap.AtomicLevel{}) && cfg.Level != baseLoggerLevel {
+		values.Set("level", cfg.Level.String())
+	}
+	if cfg.MinLevel != nil {
+		values.Set("minLevel", cfg.MinLevel.String())
+	}
+	if cfg.MaxLevel != nil {
+		values.Set("maxLevel", cfg.MaxLevel.String())
+	}
+	if len(cfg.Levels) > 0 {
+		values.Set("levels", cfg.Levels.String())
+	}
+	if cfg.ReopenOnSignal != "" {
+		values.Set("reopenOnSignal", cfg.ReopenOnSignal)
+	}
+	if cfg.Sampling != nil {
+		values.Set("sampling.initial", strconv.Itoa(cfg.Sampling.Initial))
+		values.Set("sampling.thereafter", strconv.Itoa(cfg.Sampling.Thereafter))
+		values.Set("sampling.tick", cfg.Sampling.Tick.String())
+	}
+	if cfg.Async != nil {
+		overflow, err := cfg.Async.Overflow.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		values.Set("buffered", "true")
+		values.Set("bufferSize", strconv.Itoa(cfg.Async.Size))
+		values.Set("flushInterval", cfg.Async.FlushInterval.String())
+		values.Set("overflow", string(overflow))
+	}
+
+	switch cfg.EncoderType {
+	case JSONEncoder, ConsoleEncoder, LogfmtEncoder:
+		cfg.addStandardValues(values)
+	case SyslogEncoder:
+		cfg.addStandardValues(values)
+		values["outputAddress"] = cfg.OutputAddresses
+		format, err := cfg.Format.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		values.Set("format", string(format))
+		framing, err := framingName(cfg.Framing)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("framing", framing)
+		facility, err := facilityName(cfg.Facility)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("facility", facility)
+		values.Set("hostname", cfg.Hostname)
+		values.Set("pid", strconv.Itoa(cfg.PID))
+		values.Set("app", cfg.App)
+		values.Set("msgIDKey", cfg.MsgIDKey)
+		cfg.TLS.addValues(values)
+		if err := cfg.Buffer.addValues(values); err != nil {
+			return nil, err
+		}
+	case GELFEncoder:
+		values["outputAddress"] = cfg.OutputAddresses
+		compression, err := cfg.Compression.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		values.Set("compression", string(compression))
+		values.Set("hostname", cfg.Hostname)
+		values.Set("app", cfg.App)
+		if err := cfg.Buffer.addValues(values); err != nil {
+			return nil, err
+		}
+		cfg.addErrorValues(values)
+		for k, v := range cfg.EncoderConfig.values() {
+			values.Set(k, v)
+		}
+	case JournaldEncoder:
+		values["outputAddress"] = cfg.OutputAddresses
+		values.Set("app", cfg.App)
+		cfg.addErrorValues(values)
+		for k, v := range cfg.EncoderConfig.values() {
+			values.Set(k, v)
+		}
+	}
+
+	return &url.URL{
+		Scheme:   "logger",
+		Opaque:   string(opaque),
+		RawQuery: encodeQuery(values),
+	}, nil
+}
+
+// String returns the canonical logger URI of the config.
+func (cfg Config) String() string {
+	u, err := cfg.URI()
+	if err != nil {
+		return fmt.Sprintf("Config(%v)", err)
+	}
+	return u.String()
+}
+
+var facilityNames = map[syslog.Priority]string{
+	syslog.LOG_KERN:     "kern",
+	syslog.LOG_USER:     "user",
+	syslog.LOG_MAIL:     "mail",
+	syslog.LOG_DAEMON:   "daemon",
+	syslog.LOG_AUTH:     "auth",
+	syslog.LOG_SYSLOG:   "syslog",
+	syslog.LOG_LPR:      "lpr",
+	syslog.LOG_NEWS:     "news",
+	syslog.LOG_UUCP:     "uucp",
+	syslog.LOG_CRON:     "cron",
+	syslog.LOG_AUTHPRIV: "authpriv",
+	syslog.LOG_FTP:      "ftp",
+	syslog.LOG_LOCAL0:   "local0",
+	syslog.LOG_LOCAL1:   "local1",
+	syslog.LOG_LOCAL2:   "local2",
+	syslog.LOG_LOCAL3:   "local3",
+	syslog.LOG_LOCAL4:   "local4",
+	syslog.LOG_LOCAL5:   "local5",
+	syslog.LOG_LOCAL6:   "local6",
+	syslog.LOG_LOCAL7:   "local7",
+}
+
+// encodeQuery is like url.Values.Encode, but only escapes the characters
+// which are significant to query parsing, to keep paths and lumberjack
+// settings readable.
+func encodeQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(queryEscape(k))
+			buf.WriteByte('=')
+			buf.WriteString(queryEscape(v))
+		}
+	}
+	return buf.String()
+}
+
+func queryEscape(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c <= ' ', c >= 0x7f, c == '%', c == '&', c == '+', c == '#', c == ';':
+			buf.WriteByte('%')
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&15])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
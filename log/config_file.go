@@ -0,0 +1,216 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/imperfectgo/zap-syslog/syslog"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadConfigFile loads config from a YAML or JSON file. Files with the
+// ".json" extension are decoded as JSON, anything else as YAML.
+//
+// Settings missing from the file keep their default values, as if parsed from
+// a bare logger URI. The level, if set, is a level of the config's own, which
+// is applied to the level shared by all loggers once the config is installed
+// by the log.format flag or WatchConfig, just like the log.level flag.
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config fileConfig
+	if err := unmarshalConfigFile(path, data, &config); err != nil {
+		return nil, err
+	}
+
+	c := Config(config)
+	return &c, nil
+}
+
+// LoadConfigsFile is like LoadConfigFile, but the file may also contain a
+// list of configs, one for each log target.
+func LoadConfigsFile(path string) (Configs, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfigsFile(path, data)
+}
+
+// decodeConfigsFile decodes the content of a config file, the path tells its
+// format.
+func decodeConfigsFile(path string, data []byte) (Configs, error) {
+	isList, err := isConfigList(path, data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "config: error loading "+path)
+	}
+	if !isList {
+		var config fileConfig
+		if err := unmarshalConfigFile(path, data, &config); err != nil {
+			return nil, err
+		}
+		return Configs{Config(config)}, nil
+	}
+
+	var configs []fileConfig
+	if err := unmarshalConfigFile(path, data, &configs); err != nil {
+		return nil, err
+	}
+
+	cs := make(Configs, len(configs))
+	for i, c := range configs {
+		cs[i] = Config(c)
+	}
+	return cs, nil
+}
+
+func isJSONConfigFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
+func isConfigList(path string, data []byte) (bool, error) {
+	if isJSONConfigFile(path) {
+		return bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")), nil
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return false, err
+	}
+	_, ok := v.([]interface{})
+	return ok, nil
+}
+
+func unmarshalConfigFile(path string, data []byte, v interface{}) error {
+	var err error
+	if isJSONConfigFile(path) {
+		err = json.Unmarshal(data, v)
+	} else {
+		err = yaml.UnmarshalStrict(data, v)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "config: error loading "+path)
+	}
+	return nil
+}
+
+// syslogNames parse the names of the syslog settings in config files, e.g.
+// "framing: octet-counting", their values are accepted as well.
+var syslogNames = map[string]func(string) (int, error){
+	"framing": func(s string) (int, error) {
+		framing, err := parseFraming(s)
+		return int(framing), err
+	},
+	"facility": func(s string) (int, error) {
+		facility, err := syslog.FacilityPriority(s)
+		return int(facility), err
+	},
+}
+
+// fileConfig decodes a Config on top of the default settings. The level is
+// decoded into a fresh one, so loading a file never changes the shared level.
+type fileConfig Config
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *fileConfig) UnmarshalJSON(data []byte) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	replaced := false
+	for key, parse := range syslogNames {
+		var name string
+		if raw, ok := keys[key]; ok && json.Unmarshal(raw, &name) == nil {
+			n, err := parse(name)
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing "+key)
+			}
+			keys[key] = json.RawMessage(strconv.Itoa(n))
+			replaced = true
+		}
+	}
+	if replaced {
+		var err error
+		if data, err = json.Marshal(keys); err != nil {
+			return err
+		}
+	}
+
+	v := defaultConfig
+	v.Level = zap.NewAtomicLevel()
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if _, ok := keys["level"]; !ok {
+		v.Level = defaultConfig.Level
+	}
+	*c = fileConfig(v)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *fileConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var keys map[string]interface{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+
+	replaced := false
+	for key, parse := range syslogNames {
+		if name, ok := keys[key].(string); ok {
+			n, err := parse(name)
+			if err != nil {
+				return errors.WithMessage(err, "config: error parsing "+key)
+			}
+			keys[key] = n
+			replaced = true
+		}
+	}
+	if replaced {
+		data, err := yaml.Marshal(keys)
+		if err != nil {
+			return err
+		}
+		unmarshal = func(v interface{}) error {
+			return yaml.UnmarshalStrict(data, v)
+		}
+	}
+
+	v := defaultConfig
+	v.Level = zap.NewAtomicLevel()
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if _, ok := keys["level"]; !ok {
+		v.Level = defaultConfig.Level
+	}
+	*c = fileConfig(v)
+	return nil
+}
+
+// installFileLevels makes the configs loaded from files share the level of
+// all loggers again, and returns the level of the last one with a level of
+// its own, which is to be applied to the shared level once they're installed.
+func installFileLevels(cs Configs) *zapcore.Level {
+	var level *zapcore.Level
+	for i := range cs {
+		if cs[i].Level == baseLoggerLevel {
+			continue
+		}
+		l := cs[i].Level.Level()
+		level = &l
+		cs[i].Level = baseLoggerLevel
+	}
+	return level
+}
@@ -0,0 +1,231 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imperfectgo/zap-syslog"
+	"github.com/imperfectgo/zap-syslog/syslog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	defer baseLoggerLevel.SetLevel(baseLoggerLevel.Level())
+
+	fixtures := []struct {
+		name     string
+		content  string
+		expected Config
+	}{
+		{
+			name: "logging.yaml",
+			content: `
+encoderType: console
+level: debug
+outputPaths: [stdout]
+levels:
+  db: debug
+  http.*: warn
+lumberjacks:
+  - filename: abc.log
+    compress: true
+`,
+			expected: defaultConfigWith(
+				withEncoderType(ConsoleEncoder),
+				withLevel(zapcore.DebugLevel),
+				withOutputPaths([]string{"stdout"}),
+				withLevels(NameLevels{"db": zapcore.DebugLevel, "http.*": zapcore.WarnLevel}),
+				withLumberjacks([]LumberjackConfig{{
+					Filename:   "abc.log",
+					MaxSize:    DefaultLumberjackMaxSize,
+					MaxBackups: DefaultLumberjackMaxBackups,
+					Compress:   true,
+				}}),
+			),
+		},
+		{
+			name:    "logging.json",
+			content: `{"encoderType": "syslog", "level": "debug", "framing": "octet-counting", "facility": "local3", "outputAddresses": ["tcp:localhost:514"]}`,
+			expected: defaultConfigWith(
+				withEncoderType(SyslogEncoder),
+				withLevel(zapcore.DebugLevel),
+				withSyslog([]string{"tcp:localhost:514"}, zapsyslog.OctetCountingFraming, syslog.LOG_LOCAL3),
+			),
+		},
+		{
+			name:    "sampling.json",
+			content: `{"outputPaths": ["stdout"], "sampling": {"initial": 10, "thereafter": 5, "tick": "1s"}}`,
+			expected: defaultConfigWith(
+				withOutputPaths([]string{"stdout"}),
+				withSampling(&SamplingConfig{Initial: 10, Thereafter: 5, Tick: time.Second}),
+			),
+		},
+		{
+			name:    "sampling-ns.json",
+			content: `{"outputPaths": ["stdout"], "sampling": {"initial": 10, "thereafter": 5, "tick": 1000000000}}`,
+			expected: defaultConfigWith(
+				withOutputPaths([]string{"stdout"}),
+				withSampling(&SamplingConfig{Initial: 10, Thereafter: 5, Tick: time.Second}),
+			),
+		},
+		{
+			name:    "sampling.yaml",
+			content: "outputPaths: [stdout]\nsampling:\n  initial: 10\n  thereafter: 5\n  tick: 1s\n",
+			expected: defaultConfigWith(
+				withOutputPaths([]string{"stdout"}),
+				withSampling(&SamplingConfig{Initial: 10, Thereafter: 5, Tick: time.Second}),
+			),
+		},
+		{
+			name:    "async.json",
+			content: `{"outputPaths": ["stdout"], "async": {"size": 100, "flushInterval": "30s", "overflow": "drop"}}`,
+			expected: defaultConfigWith(
+				withOutputPaths([]string{"stdout"}),
+				withAsync(&AsyncConfig{Size: 100, FlushInterval: 30 * time.Second, Overflow: OverflowDrop}),
+			),
+		},
+		{
+			name:    "async.yaml",
+			content: "outputPaths: [stdout]\nasync:\n  size: 100\n  flushInterval: 30s\n  overflow: drop\n",
+			expected: defaultConfigWith(
+				withOutputPaths([]string{"stdout"}),
+				withAsync(&AsyncConfig{Size: 100, FlushInterval: 30 * time.Second, Overflow: OverflowDrop}),
+			),
+		},
+		{
+			name:    "syslog.yaml",
+			content: "encoderType: syslog\nframing: octet-counting\nfacility: local3\noutputAddresses: [tcp:localhost:514]\n",
+			expected: defaultConfigWith(
+				withEncoderType(SyslogEncoder),
+				withSyslog([]string{"tcp:localhost:514"}, zapsyslog.OctetCountingFraming, syslog.LOG_LOCAL3),
+			),
+		},
+	}
+
+	for i, f := range fixtures {
+		baseLoggerLevel.SetLevel(zapcore.InfoLevel)
+
+		path := filepath.Join(dir, f.name)
+		if !assert.NoError(t, ioutil.WriteFile(path, []byte(f.content), 0644)) {
+			return
+		}
+
+		c, err := LoadConfigFile(path)
+		if !assert.NoError(t, err, "Error loading config, at index %d", i) {
+			return
+		}
+
+		assert.Equal(t, &f.expected, c, "config not match, at index %d", i)
+		assert.Equal(t, zapcore.InfoLevel, baseLoggerLevel.Level(), "shared level changed by loading, at index %d", i)
+	}
+
+	// broken files don't change the shared level either
+	path := filepath.Join(dir, "broken.yaml")
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte("level: debug\nencoderType: bogus\n"), 0644)) {
+		return
+	}
+	_, err = LoadConfigFile(path)
+	assert.Error(t, err)
+	assert.Equal(t, zapcore.InfoLevel, baseLoggerLevel.Level())
+}
+
+func TestInitGlobalLoggerFileLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	defer baseLoggerLevel.SetLevel(baseLoggerLevel.Level())
+	defer initGlobalLogger(defaultLogFormatURI)
+	baseLoggerLevel.SetLevel(zapcore.InfoLevel)
+
+	path := filepath.Join(dir, "logging.yaml")
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte("level: debug\noutputPaths: [stderr]\n"), 0644)) {
+		return
+	}
+	if !assert.NoError(t, initGlobalLogger("@"+path)) {
+		return
+	}
+	assert.Equal(t, zapcore.DebugLevel, baseLoggerLevel.Level(), "level not applied on install")
+
+	// the installed config follows the shared level again
+	cs := CurrentConfig()
+	if assert.Len(t, cs, 1) {
+		assert.True(t, cs[0].Level == baseLoggerLevel)
+	}
+}
+
+func TestLoadConfigsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logging.yml")
+	content := `
+- encoderType: console
+  outputPaths: [stderr]
+- encoderType: json
+  outputPaths: [stdout]
+`
+	if !assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644)) {
+		return
+	}
+
+	cs, err := LoadConfigsFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, Configs{
+		defaultConfigWith(withEncoderType(ConsoleEncoder), withOutputPaths([]string{"stderr"})),
+		defaultConfigWith(withEncoderType(JSONEncoder), withOutputPaths([]string{"stdout"})),
+	}, cs)
+}
+
+func TestLoadConfigFileMarshaled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := ParseConfigFromURIString("logger:syslog?outputAddress=tcp:localhost:514&framing=octet-counting&facility=local3&levels=db=debug&timeKey=@timestamp")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data, err := json.Marshal(c)
+	if !assert.NoError(t, err) {
+		return
+	}
+	path := filepath.Join(dir, "logging.json")
+	if !assert.NoError(t, ioutil.WriteFile(path, data, 0644)) {
+		return
+	}
+
+	c2, err := LoadConfigFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the marshaled level is the one of the file until it's installed
+	cs := Configs{*c2}
+	level := installFileLevels(cs)
+	if assert.NotNil(t, level) {
+		assert.Equal(t, baseLoggerLevel.Level(), *level)
+	}
+	assert.Equal(t, c.String(), cs[0].String())
+}
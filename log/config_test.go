@@ -1,9 +1,14 @@
 package log
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/imperfectgo/zap-syslog"
+	"github.com/imperfectgo/zap-syslog/syslog"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func defaultConfigWith(opts ...configOption) Config {
@@ -22,6 +27,12 @@ func withEncoderType(encoderType EncoderType) configOption {
 	}
 }
 
+func withLevel(level zapcore.Level) configOption {
+	return func(c *Config) {
+		c.Level = zap.NewAtomicLevelAt(level)
+	}
+}
+
 func withDevelopment(development bool) configOption {
 	return func(c *Config) {
 		c.Development = development
@@ -46,6 +57,38 @@ func withErrorOutputPaths(paths []string) configOption {
 	}
 }
 
+func withLumberjacks(lumberjacks []LumberjackConfig) configOption {
+	return func(c *Config) {
+		c.Lumberjacks = lumberjacks
+	}
+}
+
+func withLevels(levels NameLevels) configOption {
+	return func(c *Config) {
+		c.Levels = levels
+	}
+}
+
+func withSyslog(addresses []string, framing zapsyslog.Framing, facility syslog.Priority) configOption {
+	return func(c *Config) {
+		c.OutputAddresses = addresses
+		c.Framing = framing
+		c.Facility = facility
+	}
+}
+
+func withSampling(sampling *SamplingConfig) configOption {
+	return func(c *Config) {
+		c.Sampling = sampling
+	}
+}
+
+func withAsync(async *AsyncConfig) configOption {
+	return func(c *Config) {
+		c.Async = async
+	}
+}
+
 func TestParseConfigFromURI(t *testing.T) {
 	fixtures := []struct {
 		uri      string
@@ -68,6 +111,13 @@ func TestParseConfigFromURI(t *testing.T) {
 				withErrorOutputPaths([]string{"stdout", "stderr"}),
 			),
 		},
+		{
+			uri: "logger:logfmt?outputPaths=stdout",
+			expected: defaultConfigWith(
+				withEncoderType(LogfmtEncoder),
+				withOutputPaths([]string{"stdout"}),
+			),
+		},
 	}
 
 	for i, f := range fixtures {
@@ -82,3 +132,71 @@ func TestParseConfigFromURI(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigURIRoundTrip(t *testing.T) {
+	defer baseLoggerLevel.SetLevel(baseLoggerLevel.Level())
+	baseLoggerLevel.SetLevel(zapcore.InfoLevel)
+
+	fixtures := []struct {
+		uri       string
+		canonical string
+	}{
+		{
+			uri:       "logger:console?outputPaths=stdout&development=true",
+			canonical: "logger:console?development=true&disableCaller=true&disableStacktrace=false&outputPath=stdout",
+		},
+		{
+			uri:       "logger:json?outputPaths=stdout,/var/log/a%2Bb.log&errorOutputPath=stderr&lumberjack=filename=abc.log,compress=true",
+			canonical: "logger:json?development=false&disableCaller=true&disableStacktrace=false&errorOutputPath=stderr&lumberjack=filename=abc.log,maxsize=100,maxage=0,maxbackups=50,localtime=false,compress=true&outputPath=stdout&outputPath=/var/log/a%2Bb.log",
+		},
+		{
+			uri:       "logger:json?timeKey=@timestamp&timeEncoder=layout:2006-01-02&levelEncoder=capital&callerKey=-&outputPath=stdout",
+			canonical: "logger:json?callerKey=-&development=false&disableCaller=true&disableStacktrace=false&levelEncoder=capital&outputPath=stdout&timeEncoder=layout:2006-01-02&timeKey=@timestamp",
+		},
+		{
+			uri:       "logger:json?level=warn&outputPath=stdout",
+			canonical: "logger:json?development=false&disableCaller=true&disableStacktrace=false&level=warn&outputPath=stdout",
+		},
+		{
+			uri:       "logger:json?outputPath=/var/log/app.log&reopenOnSignal=SIGHUP",
+			canonical: "logger:json?development=false&disableCaller=true&disableStacktrace=false&outputPath=/var/log/app.log&reopenOnSignal=SIGHUP",
+		},
+		{
+			uri:       "logger:logfmt?outputPath=stdout&buffered=true&bufferSize=100&overflow=dropdebugfirst&levels=http=warn,db=debug",
+			canonical: "logger:logfmt?bufferSize=100&buffered=true&development=false&disableCaller=true&disableStacktrace=false&flushInterval=30s&levels=db=debug,http=warn&outputPath=stdout&overflow=dropDebugFirst",
+		},
+		{
+			uri:       "logger:syslog?outputAddress=tcp:localhost:514&framing=octet-counting&facility=LOCAL3&app=test&msgIDKey=event&bufferSize=10&dropPolicy=block",
+			canonical: "logger:syslog?app=test&bufferBytes=1048576&bufferSize=10&development=false&disableCaller=true&disableStacktrace=false&dropPolicy=block&facility=local3&format=rfc5424&framing=octet-counting&hostname=&msgIDKey=event&outputAddress=tcp:localhost:514&pid=0",
+		},
+		{
+			uri:       "logger:gelf?outputAddress=graylog:12201&outputAddress=tcp:graylog:12201&compression=zlib&hostname=web1",
+			canonical: "logger:gelf?app=&bufferBytes=1048576&bufferSize=1000&compression=zlib&development=false&disableCaller=true&disableStacktrace=false&dropPolicy=drop-oldest&hostname=web1&outputAddress=graylog:12201&outputAddress=tcp:graylog:12201",
+		},
+	}
+
+	for i, f := range fixtures {
+		c, err := ParseConfigFromURIString(f.uri)
+		if !assert.NoError(t, err, "Error parsing config, at index %d for uri %s", i, f.uri) {
+			return
+		}
+
+		u, err := c.URI()
+		if !assert.NoError(t, err, "Error marshaling config, at index %d for uri %s", i, f.uri) {
+			return
+		}
+		text := u.String()
+		assert.Equal(t, f.canonical, text, "canonical uri not match, at index %d", i)
+		assert.Equal(t, text, c.String())
+
+		c2, err := ParseConfigFromURIString(text)
+		if !assert.NoError(t, err, "Error parsing canonical uri, at index %d for uri %s", i, text) {
+			return
+		}
+		assert.Equal(t, c, c2, "config not match after round trip, at index %d for uri %s", i, f.uri)
+		if !strings.Contains(f.uri, "level=") {
+			assert.True(t, c2.Level == baseLoggerLevel, "level not shared after round trip, at index %d", i)
+		}
+	}
+	assert.Equal(t, zapcore.InfoLevel, baseLoggerLevel.Level(), "parsing the level should not change the shared level")
+}
@@ -0,0 +1,186 @@
+package log
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Configs is a list of configs, each of them describes a log target with its
+// own encoder and sinks. Loggers built from Configs write every entry to all
+// the targets.
+type Configs []Config
+
+// Build builds the configs into a single zap.Logger.
+//
+// The sinks opened by Build are never released, use Open if the logger is
+// going to be replaced at runtime.
+func (cs Configs) Build(opts ...zap.Option) (*zap.Logger, error) {
+	l, err := cs.Open(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Logger, nil
+}
+
+// Open builds the configs into a single Logger, by combining the cores of all
+// the targets with zapcore.NewTee. The Logger owns every sink opened during
+// the build and releases them on Close. Sinks opened so far are closed if
+// the build fails.
+func (cs Configs) Open(opts ...zap.Option) (*Logger, error) {
+	return cs.open(&builder{stats: &loggerStats{}}, opts...)
+}
+
+// open builds the configs with the builder, the closers of the builder are
+// called if the build fails.
+func (cs Configs) open(b *builder, opts ...zap.Option) (l *Logger, err error) {
+	defer func() {
+		if err != nil {
+			b.closers.Close()
+		}
+	}()
+
+	addCaller := cs.addCaller()
+	stackLevel := cs.stackLevel()
+
+	cores := make([]zapcore.Core, 0, len(cs))
+	errSinks := make([]zapcore.WriteSyncer, 0, len(cs))
+	var signals []os.Signal
+	for _, cfg := range cs {
+		if cfg.ReopenOnSignal != "" {
+			sig, err := parseSignal(cfg.ReopenOnSignal)
+			if err != nil {
+				return nil, errors.WithMessage(err, "config: error parsing reopenOnSignal")
+			}
+			signals = append(signals, sig)
+		}
+
+		// Annotations are added by the logger for all the targets, strip
+		// them for the targets which don't want them.
+		var wrap func(zapcore.Core) zapcore.Core
+		dropCaller := addCaller && cfg.DisableCaller
+		if dropCaller || cfg.stackLevel() > stackLevel {
+			targetStackLevel := cfg.stackLevel()
+			wrap = func(core zapcore.Core) zapcore.Core {
+				return &targetCore{
+					Core:       core,
+					dropCaller: dropCaller,
+					stackLevel: targetStackLevel,
+				}
+			}
+		}
+
+		core, errSink, err := cfg.openCore(b, wrap)
+		if err != nil {
+			return nil, err
+		}
+
+		cores = append(cores, core)
+		errSinks = append(errSinks, errSink)
+	}
+
+	errSink := zapcore.NewMultiWriteSyncer(errSinks...)
+	cfgOpts := cs.buildOptions(errSink)
+	zapOpts := make([]zap.Option, 0, len(cfgOpts)+len(opts))
+	zapOpts = append(zapOpts, cfgOpts...)
+	zapOpts = append(zapOpts, opts...)
+
+	l = &Logger{
+		Logger:  zap.New(zapcore.NewTee(cores...), zapOpts...),
+		configs: cs,
+		errSink: errSink,
+		closers: b.closers,
+		stats:   b.stats,
+		files:   b.files,
+	}
+	if len(signals) > 0 {
+		l.closers.addFunc(notifyReopen(l, signals))
+	}
+	return l, nil
+}
+
+// builder carries the state shared by the targets of a logger being built.
+type builder struct {
+	closers closerGroup
+	stats   *loggerStats
+	// files are the files of the output paths, which can be reopened.
+	files []*reopenableFile
+}
+
+func (cs Configs) addCaller() bool {
+	for _, cfg := range cs {
+		if !cfg.DisableCaller {
+			return true
+		}
+	}
+	return false
+}
+
+func (cs Configs) stackLevel() zapcore.Level {
+	level := zapcore.FatalLevel + 1
+	for _, cfg := range cs {
+		if l := cfg.stackLevel(); l < level {
+			level = l
+		}
+	}
+	return level
+}
+
+func (cs Configs) buildOptions(errSink zapcore.WriteSyncer) []zap.Option {
+	opts := []zap.Option{zap.ErrorOutput(errSink)}
+
+	for _, cfg := range cs {
+		if cfg.Development {
+			opts = append(opts, zap.Development())
+			break
+		}
+	}
+
+	if cs.addCaller() {
+		opts = append(opts, zap.AddCaller())
+	}
+
+	if stackLevel := cs.stackLevel(); stackLevel <= zapcore.FatalLevel {
+		opts = append(opts, zap.AddStacktrace(stackLevel))
+	}
+
+	return opts
+}
+
+// targetCore strips the caller and stacktrace annotations not wanted by a
+// target, since they are added by the logger for all the targets. It must
+// wrap the cores writing to the sinks directly.
+type targetCore struct {
+	zapcore.Core
+
+	dropCaller bool
+	stackLevel zapcore.Level
+}
+
+func (c *targetCore) With(fields []zapcore.Field) zapcore.Core {
+	return &targetCore{
+		Core:       c.Core.With(fields),
+		dropCaller: c.dropCaller,
+		stackLevel: c.stackLevel,
+	}
+}
+
+func (c *targetCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *targetCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.dropCaller {
+		ent.Caller = zapcore.EntryCaller{}
+	}
+	if ent.Level < c.stackLevel {
+		ent.Stack = ""
+	}
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type ctxKey struct{}
+
+// ctxLogger is the logger carried by a context, either a logger set by
+// WithContext, or the fields to add to the global logger.
+type ctxLogger struct {
+	logger *zap.Logger
+	// quickL is logger with the caller skip of the package functions.
+	quickL *zap.Logger
+	fields []zapcore.Field
+}
+
+func ctxLoggerFrom(ctx context.Context) *ctxLogger {
+	if ctx == nil {
+		return nil
+	}
+	l, _ := ctx.Value(ctxKey{}).(*ctxLogger)
+	return l
+}
+
+// WithContext returns a copy of ctx carrying the logger, which is returned by
+// FromContext and used by the context-aware package functions such as
+// InfoCtx.
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &ctxLogger{
+		logger: logger,
+		quickL: logger.WithOptions(zap.AddCallerSkip(quickLDepth)),
+	})
+}
+
+// WithFields returns a copy of ctx carrying the fields, e.g. the request ID,
+// on top of the logger or the fields carried by ctx already. Without a logger
+// set by WithContext, the fields are added to the current global logger, so
+// the context keeps up with ReplaceGlobals.
+func WithFields(ctx context.Context, fields ...zapcore.Field) context.Context {
+	prev := ctxLoggerFrom(ctx)
+	if prev != nil && prev.logger != nil {
+		return WithContext(ctx, prev.logger.With(fields...))
+	}
+
+	var l ctxLogger
+	if prev != nil {
+		l.fields = make([]zapcore.Field, 0, len(prev.fields)+len(fields))
+		l.fields = append(l.fields, prev.fields...)
+	}
+	l.fields = append(l.fields, fields...)
+	return context.WithValue(ctx, ctxKey{}, &l)
+}
+
+// FromContext returns the logger carried by ctx, along with the fields added
+// by WithFields and the ones extracted by the registered ContextExtractors.
+// It falls back to the global logger.
+//
+// It's safe for concurrent use.
+func FromContext(ctx context.Context) *zap.Logger {
+	var logger *zap.Logger
+	var fields []zapcore.Field
+	l := ctxLoggerFrom(ctx)
+	switch {
+	case l == nil:
+		logger = L()
+	case l.logger != nil:
+		logger = l.logger
+	default:
+		logger = L()
+		fields = l.fields
+	}
+
+	fields = extractFields(ctx, fields)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
+
+// Ctx is a shorthand for FromContext, e.g. log.Ctx(ctx).Info("done").
+func Ctx(ctx context.Context) *zap.Logger {
+	return FromContext(ctx)
+}
+
+// quickCtxL returns the logger of ctx for the package functions, along with
+// the fields of ctx to add to the global logger, which are passed with the
+// fields of the call site once the entry is known to be logged.
+func quickCtxL(ctx context.Context) (*zap.Logger, []zapcore.Field) {
+	l := ctxLoggerFrom(ctx)
+	switch {
+	case l == nil:
+		return globalQuickL, nil
+	case l.quickL != nil:
+		return l.quickL, nil
+	default:
+		return globalQuickL, l.fields
+	}
+}
+
+// ctxFields returns the fields of ctx, followed by the ones extracted from
+// ctx and the fields of the call site.
+func ctxFields(ctx context.Context, carried, fields []zapcore.Field) []zapcore.Field {
+	fields = extractFields(ctx, fields)
+	if len(carried) == 0 {
+		return fields
+	}
+	return append(carried[:len(carried):len(carried)], fields...)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	defer ReplaceGlobals(zap.New(core, zap.AddCaller()))()
+
+	ctx := WithFields(context.Background(), zap.String("requestID", "r1"))
+	ctx = WithFields(ctx, zap.String("user", "jane"))
+	InfoCtx(ctx, "a")
+	Ctx(ctx).Info("b")
+	InfoCtx(context.Background(), "c")
+
+	var other bytes.Buffer
+	otherCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&other), zapcore.DebugLevel)
+	ctx = WithContext(ctx, zap.New(otherCore, zap.AddCaller()).Named("sub"))
+	WarnCtx(WithFields(ctx, zap.Int("n", 1)), "d")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"caller":"log/context_test.go:`)
+		assert.Contains(t, lines[0], `"msg":"a","requestID":"r1","user":"jane"`)
+		assert.Contains(t, lines[1], `"caller":"log/context_test.go:`)
+		assert.Contains(t, lines[1], `"msg":"b","requestID":"r1","user":"jane"`)
+		assert.Contains(t, lines[2], `"msg":"c"}`)
+	}
+	assert.Contains(t, other.String(), `"logger":"sub","caller":"log/context_test.go:`)
+	assert.Contains(t, other.String(), `"msg":"d","n":1`)
+}
+
+func TestContextLoggerDisabled(t *testing.T) {
+	prev, _ := extractors.Load().([]ContextExtractor)
+	defer extractors.Store(prev)
+	var extracted int
+	RegisterContextExtractor(func(ctx context.Context) []zapcore.Field {
+		extracted++
+		return nil
+	})
+
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(defaultJSONEncoderConfig), zapcore.AddSync(&buf), zapcore.InfoLevel)
+	defer ReplaceGlobals(zap.New(core))()
+
+	ctx := WithFields(context.Background(), zap.String("requestID", "r1"))
+	DebugCtx(ctx, "a")
+	assert.Equal(t, 0, extracted)
+	assert.Equal(t, 0, buf.Len())
+
+	InfoCtx(ctx, "b", zap.Int("n", 1))
+	assert.Equal(t, 1, extracted)
+	assert.Contains(t, buf.String(), `"msg":"b","requestID":"r1","n":1`)
+}
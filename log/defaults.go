@@ -9,6 +9,8 @@ import (
 
 const (
 	defaultLogFormatURI = "logger:json?outputPaths=stderr"
+
+	levelFlagHelp = `Only log messages with the given severity or above. Valid levels: [debug, info, warn, error, dpanic, panic, fatal]. Add levels of named loggers and their children, e.g. "info,db=debug,http.*=warn"`
 )
 
 var (
@@ -40,7 +42,43 @@ var (
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
-	defaultSyslogEncoderConfig = zapsyslog.SyslogEncoderConfig{
+	defaultLogfmtEncoderConfig = zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	defaultGELFEncoderConfig = GELFEncoderConfig{
+		EncoderConfig: zapcore.EncoderConfig{
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			StacktraceKey:  "stacktrace",
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.SecondsDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		},
+	}
+	defaultJournaldEncoderConfig = JournaldEncoderConfig{
+		EncoderConfig: zapcore.EncoderConfig{
+			NameKey:        "logger",
+			StacktraceKey:  "stacktrace",
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		},
+	}
+	defaultBufferConfig = BufferConfig{
+		Size:       1000,
+		Bytes:      1 << 20,
+		DropPolicy: DropOldest,
+	}
+	defaultSyslogEncoderConfig = SyslogEncoderConfig{
 		EncoderConfig: defaultJSONEncoderConfig,
 		Framing:       zapsyslog.DefaultFraming,
 		Facility:      syslog.LOG_LOCAL0,
@@ -0,0 +1,356 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// OmitKey omits the corresponding portion of log entries when used as a key
+// in EncoderConfig.
+const OmitKey = "-"
+
+// EncoderConfig customizes the keys and the primitive encoders used by an
+// encoder. Empty settings keep the defaults of the encoder type.
+type EncoderConfig struct {
+	TimeKey       string `json:"timeKey,omitempty" yaml:"timeKey,omitempty"`
+	LevelKey      string `json:"levelKey,omitempty" yaml:"levelKey,omitempty"`
+	MessageKey    string `json:"messageKey,omitempty" yaml:"messageKey,omitempty"`
+	CallerKey     string `json:"callerKey,omitempty" yaml:"callerKey,omitempty"`
+	StacktraceKey string `json:"stacktraceKey,omitempty" yaml:"stacktraceKey,omitempty"`
+	NameKey       string `json:"nameKey,omitempty" yaml:"nameKey,omitempty"`
+	// FunctionKey, if set, adds the name of the calling function to log
+	// entries under the key. Nothing is added when caller annotation is
+	// disabled.
+	FunctionKey string `json:"functionKey,omitempty" yaml:"functionKey,omitempty"`
+	// TimeEncoder is one of "epoch", "millis", "nanos", "iso8601", "rfc3339",
+	// "rfc3339nano", or "layout:" followed by a Go time layout.
+	TimeEncoder string `json:"timeEncoder,omitempty" yaml:"timeEncoder,omitempty"`
+	// LevelEncoder is one of "lowercase", "capital", "color" and
+	// "capitalColor".
+	LevelEncoder string `json:"levelEncoder,omitempty" yaml:"levelEncoder,omitempty"`
+	// CallerEncoder is one of "short" and "full".
+	CallerEncoder string `json:"callerEncoder,omitempty" yaml:"callerEncoder,omitempty"`
+	// DurationEncoder is one of "seconds", "millis", "nanos" and "string".
+	DurationEncoder string `json:"durationEncoder,omitempty" yaml:"durationEncoder,omitempty"`
+	// Schema, if set, is one of ECSSchema and OTelSchema, it makes the json
+	// encoder emit the fields defined by the schema instead of the keys.
+	Schema string `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// values returns the settings of the config keyed by their names in logger
+// URIs, empty settings are omitted.
+func (c EncoderConfig) values() map[string]string {
+	m := map[string]string{
+		"timeKey":         c.TimeKey,
+		"levelKey":        c.LevelKey,
+		"messageKey":      c.MessageKey,
+		"callerKey":       c.CallerKey,
+		"stacktraceKey":   c.StacktraceKey,
+		"nameKey":         c.NameKey,
+		"functionKey":     c.FunctionKey,
+		"timeEncoder":     c.TimeEncoder,
+		"levelEncoder":    c.LevelEncoder,
+		"callerEncoder":   c.CallerEncoder,
+		"durationEncoder": c.DurationEncoder,
+		"schema":          c.Schema,
+	}
+	for k, v := range m {
+		if v == "" {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// set sets a setting by its name in logger URIs, it reports whether the
+// name is known.
+func (c *EncoderConfig) set(k, v string) (bool, error) {
+	switch k {
+	case "timeKey":
+		c.TimeKey = v
+	case "levelKey":
+		c.LevelKey = v
+	case "messageKey":
+		c.MessageKey = v
+	case "callerKey":
+		c.CallerKey = v
+	case "stacktraceKey":
+		c.StacktraceKey = v
+	case "nameKey":
+		c.NameKey = v
+	case "functionKey":
+		c.FunctionKey = v
+	case "timeEncoder":
+		if _, err := parseTimeEncoder(v); err != nil {
+			return true, err
+		}
+		c.TimeEncoder = v
+	case "levelEncoder":
+		if _, err := parseLevelEncoder(v); err != nil {
+			return true, err
+		}
+		c.LevelEncoder = v
+	case "callerEncoder":
+		if _, err := parseCallerEncoder(v); err != nil {
+			return true, err
+		}
+		c.CallerEncoder = v
+	case "durationEncoder":
+		if _, err := parseDurationEncoder(v); err != nil {
+			return true, err
+		}
+		c.DurationEncoder = v
+	case "schema":
+		if _, err := parseSchema(v); err != nil {
+			return true, err
+		}
+		c.Schema = v
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// apply applies the settings on top of the encoder config of an encoder
+// type.
+func (c EncoderConfig) apply(ec zapcore.EncoderConfig) (zapcore.EncoderConfig, error) {
+	setKey := func(key *string, v string) {
+		switch v {
+		case "":
+		case OmitKey:
+			*key = ""
+		default:
+			*key = v
+		}
+	}
+	setKey(&ec.TimeKey, c.TimeKey)
+	setKey(&ec.LevelKey, c.LevelKey)
+	setKey(&ec.MessageKey, c.MessageKey)
+	setKey(&ec.CallerKey, c.CallerKey)
+	setKey(&ec.StacktraceKey, c.StacktraceKey)
+	setKey(&ec.NameKey, c.NameKey)
+
+	var err error
+	if c.TimeEncoder != "" {
+		if ec.EncodeTime, err = parseTimeEncoder(c.TimeEncoder); err != nil {
+			return ec, err
+		}
+	}
+	if c.LevelEncoder != "" {
+		if ec.EncodeLevel, err = parseLevelEncoder(c.LevelEncoder); err != nil {
+			return ec, err
+		}
+	}
+	if c.CallerEncoder != "" {
+		if ec.EncodeCaller, err = parseCallerEncoder(c.CallerEncoder); err != nil {
+			return ec, err
+		}
+	}
+	if c.DurationEncoder != "" {
+		if ec.EncodeDuration, err = parseDurationEncoder(c.DurationEncoder); err != nil {
+			return ec, err
+		}
+	}
+	return ec, nil
+}
+
+// wrapEncoder adds the extra keys of the config to entries encoded by enc.
+func (c EncoderConfig) wrapEncoder(enc zapcore.Encoder) zapcore.Encoder {
+	if c.FunctionKey == "" || c.FunctionKey == OmitKey {
+		return enc
+	}
+	return &functionEncoder{Encoder: enc, key: c.FunctionKey}
+}
+
+func parseTimeEncoder(s string) (zapcore.TimeEncoder, error) {
+	if strings.HasPrefix(s, "layout:") {
+		layout := s[len("layout:"):]
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.Format(layout))
+		}, nil
+	}
+
+	switch s {
+	case "epoch":
+		return zapcore.EpochTimeEncoder, nil
+	case "millis":
+		return zapcore.EpochMillisTimeEncoder, nil
+	case "nanos":
+		return zapcore.EpochNanosTimeEncoder, nil
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder, nil
+	case "rfc3339":
+		return rfc3339TimeEncoder, nil
+	case "rfc3339nano":
+		return rfc3339NanoTimeEncoder, nil
+	}
+	return nil, fmt.Errorf("unknown time encoder: %s", s)
+}
+
+func rfc3339TimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.Format(time.RFC3339))
+}
+
+func rfc3339NanoTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.Format(time.RFC3339Nano))
+}
+
+func parseLevelEncoder(s string) (zapcore.LevelEncoder, error) {
+	switch s {
+	case "lowercase":
+		return zapcore.LowercaseLevelEncoder, nil
+	case "capital":
+		return zapcore.CapitalLevelEncoder, nil
+	case "color":
+		return zapcore.LowercaseColorLevelEncoder, nil
+	case "capitalColor":
+		return zapcore.CapitalColorLevelEncoder, nil
+	}
+	return nil, fmt.Errorf("unknown level encoder: %s", s)
+}
+
+func parseCallerEncoder(s string) (zapcore.CallerEncoder, error) {
+	switch s {
+	case "short":
+		return zapcore.ShortCallerEncoder, nil
+	case "full":
+		return zapcore.FullCallerEncoder, nil
+	}
+	return nil, fmt.Errorf("unknown caller encoder: %s", s)
+}
+
+func parseDurationEncoder(s string) (zapcore.DurationEncoder, error) {
+	switch s {
+	case "seconds":
+		return zapcore.SecondsDurationEncoder, nil
+	case "millis":
+		return millisDurationEncoder, nil
+	case "nanos":
+		return zapcore.NanosDurationEncoder, nil
+	case "string":
+		return zapcore.StringDurationEncoder, nil
+	}
+	return nil, fmt.Errorf("unknown duration encoder: %s", s)
+}
+
+func millisDurationEncoder(d time.Duration, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendFloat64(float64(d) / float64(time.Millisecond))
+}
+
+// functionEncoder adds the name of the calling function to log entries.
+type functionEncoder struct {
+	zapcore.Encoder
+
+	key string
+}
+
+func (enc *functionEncoder) Clone() zapcore.Encoder {
+	return &functionEncoder{Encoder: enc.Encoder.Clone(), key: enc.key}
+}
+
+func (enc *functionEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if ent.Caller.Defined {
+		if fn := runtime.FuncForPC(ent.Caller.PC); fn != nil {
+			fields = append(fields[:len(fields):len(fields)], zap.String(enc.key, fn.Name()))
+		}
+	}
+	return enc.Encoder.EncodeEntry(ent, fields)
+}
+
+// newJSONEncoder creates the JSON encoder of zap, which leaves the time out
+// of entries with the zero time.
+func newJSONEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return newZeroTimeEncoder(zapcore.NewJSONEncoder, cfg, 1, ',')
+}
+
+// newConsoleEncoder creates the console encoder of zap, which leaves the
+// time out of entries with the zero time.
+func newConsoleEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return newZeroTimeEncoder(zapcore.NewConsoleEncoder, cfg, 0, '\t')
+}
+
+// zeroTimeEncoder removes the time from the entries with the zero time, e.g.
+// slog records without a time, which the encoders of zap always encode.
+type zeroTimeEncoder struct {
+	zapcore.Encoder
+
+	// timeOnly encodes the time of entries the way Encoder does, wrapped in
+	// offset bytes on each side and followed by the line ending.
+	timeOnly   zapcore.Encoder
+	lineEnding string
+	offset     int
+	// sep is the separator of the time and the rest of the entry.
+	sep byte
+}
+
+func newZeroTimeEncoder(newEncoder func(zapcore.EncoderConfig) zapcore.Encoder, cfg zapcore.EncoderConfig, offset int, sep byte) zapcore.Encoder {
+	enc := newEncoder(cfg)
+	if cfg.TimeKey == "" || cfg.EncodeTime == nil {
+		return enc
+	}
+
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return &zeroTimeEncoder{
+		Encoder: enc,
+		timeOnly: newEncoder(zapcore.EncoderConfig{
+			TimeKey:    cfg.TimeKey,
+			EncodeTime: cfg.EncodeTime,
+			LineEnding: lineEnding,
+		}),
+		lineEnding: lineEnding,
+		offset:     offset,
+		sep:        sep,
+	}
+}
+
+func (enc *zeroTimeEncoder) Clone() zapcore.Encoder {
+	clone := *enc
+	clone.Encoder = enc.Encoder.Clone()
+	return &clone
+}
+
+func (enc *zeroTimeEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := enc.Encoder.EncodeEntry(ent, fields)
+	if err != nil || !ent.Time.IsZero() {
+		return buf, err
+	}
+
+	t, err := enc.timeOnly.EncodeEntry(ent, nil)
+	if err != nil {
+		buf.Free()
+		return nil, err
+	}
+	defer t.Free()
+
+	tb := t.Bytes()
+	if len(tb) < len(enc.lineEnding)+2*enc.offset {
+		return buf, nil
+	}
+	// the time comes before the fields, so the first match is the time
+	encoded := tb[enc.offset : len(tb)-len(enc.lineEnding)-enc.offset]
+	b := buf.Bytes()
+	start := bytes.Index(b, encoded)
+	if start < 0 {
+		return buf, nil
+	}
+	end := start + len(encoded)
+	if end < len(b) && b[end] == enc.sep {
+		end++
+	} else if start > 0 && b[start-1] == enc.sep {
+		start--
+	}
+	n := start + copy(b[start:], b[end:])
+	buf.Reset()
+	buf.Write(b[:n])
+	return buf, nil
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	extractorsMu sync.Mutex
+	// extractors holds a []ContextExtractor, which is replaced on every
+	// registration.
+	extractors atomic.Value
+)
+
+// ContextExtractor extracts fields from a context, e.g. the IDs of the trace
+// and span of a tracing library. It returns nil if there is nothing to add.
+type ContextExtractor func(ctx context.Context) []zapcore.Field
+
+// RegisterContextExtractor registers an extractor, the fields extracted from
+// the contexts are added to the entries logged by the context-aware package
+// functions such as InfoCtx, and by the loggers returned by FromContext.
+// It's usually called on initialization, e.g.
+//
+//	log.RegisterContextExtractor(log.TraceparentExtractor)
+//
+// It's safe for concurrent use.
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	prev, _ := extractors.Load().([]ContextExtractor)
+	fns := make([]ContextExtractor, 0, len(prev)+1)
+	fns = append(fns, prev...)
+	fns = append(fns, fn)
+	extractors.Store(fns)
+}
+
+// extractFields returns the fields extracted from ctx by the registered
+// extractors, followed by the fields.
+func extractFields(ctx context.Context, fields []zapcore.Field) []zapcore.Field {
+	fns, _ := extractors.Load().([]ContextExtractor)
+	if ctx == nil || len(fns) == 0 {
+		return fields
+	}
+
+	var extracted []zapcore.Field
+	for _, fn := range fns {
+		extracted = append(extracted, fn(ctx)...)
+	}
+	if len(extracted) == 0 {
+		return fields
+	}
+	return append(extracted, fields...)
+}
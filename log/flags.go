@@ -2,7 +2,7 @@ package log
 
 import (
 	"flag"
-	"net/url"
+	"strings"
 )
 
 // AddFlags adds the flags used by this package to the given FlagSet. That's
@@ -13,18 +13,13 @@ func AddFlags(fs *flag.FlagSet) error {
 	fs.Var(
 		levelFlag("info"),
 		"log.level",
-		"Only log messages with the given severity or above. Valid levels: [debug, info, warn, error, dpanic, panic, fatal]",
+		levelFlagHelp,
 	)
 
-	u, err := url.Parse(defaultLogFormatURI)
-	if err != nil {
-		return err
-	}
-
 	fs.Var(
-		logFormatFlag(*u),
+		&logFormatFlag{defaultFormat: defaultLogFormatURI},
 		"log.format",
-		`Set the log target and format. Example: "logger:console?disableCaller=false&development=true&outputPaths=stdout&errorOutputPaths=stderr" or "logger:json?disableStacktrace=true"`,
+		`Set the log target and format, repeat it to log to multiple targets. Example: "logger:console?disableCaller=false&development=true&outputPaths=stdout&errorOutputPaths=stderr" or "logger:json?disableStacktrace=true", prefix a path with "@" to load config from a YAML/JSON file`,
 	)
 
 	return nil
@@ -39,18 +34,50 @@ func (f levelFlag) String() string {
 
 // Set implements flag.Value interface.
 func (f levelFlag) Set(level string) error {
-	return baseLoggerLevel.UnmarshalText([]byte(level))
+	return setLevelRules(level)
 }
 
-type logFormatFlag url.URL
+// logFormatFlag is a repeatable flag, each value adds a log target.
+type logFormatFlag struct {
+	defaultFormat string
+	formats       []string
+}
 
-// String implements flag.Value.
-func (f logFormatFlag) String() string {
-	u := url.URL(f)
-	return u.String()
+// String implements flag.Value, the canonical URIs of the active targets are
+// reported once the flag is in use.
+func (f *logFormatFlag) String() string {
+	if f == nil || f.defaultFormat == "" {
+		return ""
+	}
+
+	if cs := CurrentConfig(); cs != nil {
+		uris := make([]string, 0, len(cs))
+		for _, c := range cs {
+			u, err := c.URI()
+			if err != nil {
+				break
+			}
+			uris = append(uris, u.String())
+		}
+		if len(uris) == len(cs) {
+			return strings.Join(uris, " ")
+		}
+	}
+
+	if len(f.formats) == 0 {
+		return f.defaultFormat
+	}
+	return strings.Join(f.formats, " ")
 }
 
-// Set implements flag.Value.
-func (f logFormatFlag) Set(format string) error {
-	return initGlobalLogger(format)
+// Set implements flag.Value, the global logger is rebuilt with the targets
+// given so far.
+func (f *logFormatFlag) Set(format string) error {
+	formats := append(f.formats[:len(f.formats):len(f.formats)], format)
+	if err := initGlobalLogger(formats...); err != nil {
+		return err
+	}
+
+	f.formats = formats
+	return nil
 }
@@ -0,0 +1,250 @@
+package log
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// flatField is a field flattened by flatEncoder, its value is one of string,
+// bool, int64, uint64, float64 and complex128.
+type flatField struct {
+	Key   string
+	Value interface{}
+}
+
+var (
+	_ zapcore.ObjectEncoder = &flatEncoder{}
+	_ zapcore.ArrayEncoder  = &flatArrayEncoder{}
+)
+
+// flatEncoder is a zapcore.ObjectEncoder which flattens nested objects and
+// arrays into fields with dotted keys, e.g. "user.name" and "ids.0", for
+// encoders of formats without nesting.
+type flatEncoder struct {
+	cfg    *zapcore.EncoderConfig
+	fields []flatField
+	// prefix of the keys, for nested objects and namespaces
+	prefix string
+}
+
+func newFlatEncoder(cfg *zapcore.EncoderConfig) *flatEncoder {
+	return &flatEncoder{cfg: cfg}
+}
+
+func (enc *flatEncoder) clone() *flatEncoder {
+	fields := make([]flatField, len(enc.fields), len(enc.fields)+8)
+	copy(fields, enc.fields)
+	return &flatEncoder{
+		cfg:    enc.cfg,
+		fields: fields,
+		prefix: enc.prefix,
+	}
+}
+
+func (enc *flatEncoder) add(key string, value interface{}) {
+	enc.fields = append(enc.fields, flatField{Key: enc.prefix + key, Value: value})
+}
+
+// addPrimitives adds the values appended by a primitive encoder, such as a
+// zapcore.TimeEncoder. Multiple values are flattened like arrays.
+func (enc *flatEncoder) addPrimitives(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	var values primitiveValues
+	encode(&values)
+	switch len(values) {
+	case 0:
+	case 1:
+		enc.add(key, values[0])
+	default:
+		for i, v := range values {
+			enc.add(key+"."+strconv.Itoa(i), v)
+		}
+	}
+}
+
+func (enc *flatEncoder) addNested(key string, marshal func(*flatEncoder) error) error {
+	prefix := enc.prefix
+	enc.prefix = prefix + key + "."
+	err := marshal(enc)
+	enc.prefix = prefix
+	return err
+}
+
+func (enc *flatEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	return enc.addNested(key, func(enc *flatEncoder) error {
+		return arr.MarshalLogArray(&flatArrayEncoder{enc: enc})
+	})
+}
+
+func (enc *flatEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	return enc.addNested(key, func(enc *flatEncoder) error {
+		return obj.MarshalLogObject(enc)
+	})
+}
+
+func (enc *flatEncoder) AddBinary(key string, val []byte) {
+	enc.add(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (enc *flatEncoder) AddByteString(key string, val []byte) { enc.add(key, string(val)) }
+func (enc *flatEncoder) AddBool(key string, val bool)         { enc.add(key, val) }
+func (enc *flatEncoder) AddComplex128(key string, val complex128) {
+	enc.add(key, val)
+}
+func (enc *flatEncoder) AddComplex64(key string, val complex64) {
+	enc.add(key, complex128(val))
+}
+
+func (enc *flatEncoder) AddDuration(key string, val time.Duration) {
+	if enc.cfg == nil || enc.cfg.EncodeDuration == nil {
+		enc.add(key, val.String())
+		return
+	}
+	enc.addPrimitives(key, func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeDuration(val, pae)
+	})
+}
+
+func (enc *flatEncoder) AddFloat64(key string, val float64) { enc.add(key, val) }
+func (enc *flatEncoder) AddFloat32(key string, val float32) { enc.add(key, float64(val)) }
+func (enc *flatEncoder) AddInt(key string, val int)         { enc.add(key, int64(val)) }
+func (enc *flatEncoder) AddInt64(key string, val int64)     { enc.add(key, val) }
+func (enc *flatEncoder) AddInt32(key string, val int32)     { enc.add(key, int64(val)) }
+func (enc *flatEncoder) AddInt16(key string, val int16)     { enc.add(key, int64(val)) }
+func (enc *flatEncoder) AddInt8(key string, val int8)       { enc.add(key, int64(val)) }
+func (enc *flatEncoder) AddString(key, val string)          { enc.add(key, val) }
+
+func (enc *flatEncoder) AddTime(key string, val time.Time) {
+	if enc.cfg == nil || enc.cfg.EncodeTime == nil {
+		enc.add(key, val.Format(time.RFC3339Nano))
+		return
+	}
+	enc.addPrimitives(key, func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeTime(val, pae)
+	})
+}
+
+func (enc *flatEncoder) AddUint(key string, val uint)       { enc.add(key, uint64(val)) }
+func (enc *flatEncoder) AddUint64(key string, val uint64)   { enc.add(key, val) }
+func (enc *flatEncoder) AddUint32(key string, val uint32)   { enc.add(key, uint64(val)) }
+func (enc *flatEncoder) AddUint16(key string, val uint16)   { enc.add(key, uint64(val)) }
+func (enc *flatEncoder) AddUint8(key string, val uint8)     { enc.add(key, uint64(val)) }
+func (enc *flatEncoder) AddUintptr(key string, val uintptr) { enc.add(key, uint64(val)) }
+
+func (enc *flatEncoder) AddReflected(key string, obj interface{}) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	enc.add(key, string(b))
+	return nil
+}
+
+func (enc *flatEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + key + "."
+}
+
+// flatArrayEncoder flattens array elements into fields keyed by their
+// indexes.
+type flatArrayEncoder struct {
+	enc *flatEncoder
+	i   int
+}
+
+func (a *flatArrayEncoder) next() string {
+	key := strconv.Itoa(a.i)
+	a.i++
+	return key
+}
+
+func (a *flatArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return a.enc.AddArray(a.next(), arr)
+}
+
+func (a *flatArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return a.enc.AddObject(a.next(), obj)
+}
+
+func (a *flatArrayEncoder) AppendReflected(val interface{}) error {
+	return a.enc.AddReflected(a.next(), val)
+}
+
+func (a *flatArrayEncoder) AppendBool(val bool)              { a.enc.AddBool(a.next(), val) }
+func (a *flatArrayEncoder) AppendByteString(val []byte)      { a.enc.AddByteString(a.next(), val) }
+func (a *flatArrayEncoder) AppendComplex128(val complex128)  { a.enc.AddComplex128(a.next(), val) }
+func (a *flatArrayEncoder) AppendComplex64(val complex64)    { a.enc.AddComplex64(a.next(), val) }
+func (a *flatArrayEncoder) AppendDuration(val time.Duration) { a.enc.AddDuration(a.next(), val) }
+func (a *flatArrayEncoder) AppendFloat64(val float64)        { a.enc.AddFloat64(a.next(), val) }
+func (a *flatArrayEncoder) AppendFloat32(val float32)        { a.enc.AddFloat32(a.next(), val) }
+func (a *flatArrayEncoder) AppendInt(val int)                { a.enc.AddInt(a.next(), val) }
+func (a *flatArrayEncoder) AppendInt64(val int64)            { a.enc.AddInt64(a.next(), val) }
+func (a *flatArrayEncoder) AppendInt32(val int32)            { a.enc.AddInt32(a.next(), val) }
+func (a *flatArrayEncoder) AppendInt16(val int16)            { a.enc.AddInt16(a.next(), val) }
+func (a *flatArrayEncoder) AppendInt8(val int8)              { a.enc.AddInt8(a.next(), val) }
+func (a *flatArrayEncoder) AppendString(val string)          { a.enc.AddString(a.next(), val) }
+func (a *flatArrayEncoder) AppendTime(val time.Time)         { a.enc.AddTime(a.next(), val) }
+func (a *flatArrayEncoder) AppendUint(val uint)              { a.enc.AddUint(a.next(), val) }
+func (a *flatArrayEncoder) AppendUint64(val uint64)          { a.enc.AddUint64(a.next(), val) }
+func (a *flatArrayEncoder) AppendUint32(val uint32)          { a.enc.AddUint32(a.next(), val) }
+func (a *flatArrayEncoder) AppendUint16(val uint16)          { a.enc.AddUint16(a.next(), val) }
+func (a *flatArrayEncoder) AppendUint8(val uint8)            { a.enc.AddUint8(a.next(), val) }
+func (a *flatArrayEncoder) AppendUintptr(val uintptr)        { a.enc.AddUintptr(a.next(), val) }
+
+// primitiveValues collects the values appended by primitive encoders, such
+// as zapcore.TimeEncoder and zapcore.LevelEncoder.
+type primitiveValues []interface{}
+
+// first returns the first value appended, or the fallback if none.
+func (p primitiveValues) first(fallback interface{}) interface{} {
+	if len(p) == 0 {
+		return fallback
+	}
+	return p[0]
+}
+
+func (p *primitiveValues) AppendBool(v bool)             { *p = append(*p, v) }
+func (p *primitiveValues) AppendByteString(v []byte)     { *p = append(*p, string(v)) }
+func (p *primitiveValues) AppendComplex128(v complex128) { *p = append(*p, v) }
+func (p *primitiveValues) AppendComplex64(v complex64)   { *p = append(*p, complex128(v)) }
+func (p *primitiveValues) AppendFloat64(v float64)       { *p = append(*p, v) }
+func (p *primitiveValues) AppendFloat32(v float32)       { *p = append(*p, float64(v)) }
+func (p *primitiveValues) AppendInt(v int)               { *p = append(*p, int64(v)) }
+func (p *primitiveValues) AppendInt64(v int64)           { *p = append(*p, v) }
+func (p *primitiveValues) AppendInt32(v int32)           { *p = append(*p, int64(v)) }
+func (p *primitiveValues) AppendInt16(v int16)           { *p = append(*p, int64(v)) }
+func (p *primitiveValues) AppendInt8(v int8)             { *p = append(*p, int64(v)) }
+func (p *primitiveValues) AppendString(v string)         { *p = append(*p, v) }
+func (p *primitiveValues) AppendUint(v uint)             { *p = append(*p, uint64(v)) }
+func (p *primitiveValues) AppendUint64(v uint64)         { *p = append(*p, v) }
+func (p *primitiveValues) AppendUint32(v uint32)         { *p = append(*p, uint64(v)) }
+func (p *primitiveValues) AppendUint16(v uint16)         { *p = append(*p, uint64(v)) }
+func (p *primitiveValues) AppendUint8(v uint8)           { *p = append(*p, uint64(v)) }
+func (p *primitiveValues) AppendUintptr(v uintptr)       { *p = append(*p, uint64(v)) }
+
+// formatFlatValue formats a flattened value as text.
+func formatFlatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case complex128:
+		r, i := real(v), imag(v)
+		sign := "+"
+		if i < 0 || math.IsNaN(i) || math.Signbit(i) {
+			sign = ""
+		}
+		return strconv.FormatFloat(r, 'f', -1, 64) + sign + strconv.FormatFloat(i, 'f', -1, 64) + "i"
+	}
+	return ""
+}
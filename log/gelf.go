@@ -0,0 +1,341 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// gelfChunkSize is the maximum size of UDP datagrams, which is safe for
+	// most networks.
+	gelfChunkSize = 1420
+	// gelfMaxChunks is the maximum number of chunks of a GELF message.
+	gelfMaxChunks = 128
+	// gelfChunkHeaderSize is the size of the magic bytes, message ID,
+	// sequence number and sequence count of each chunk.
+	gelfChunkHeaderSize = 12
+)
+
+var (
+	_ zapcore.WriteSyncer = &gelfUDPSyncer{}
+	_ zapcore.WriteSyncer = &nullFramedSyncer{}
+
+	gelfPool             = buffer.NewPool()
+	errGELFMessageTooBig = errors.New("log: GELF message too big to be chunked")
+)
+
+// GELFCompression is the compression of GELF messages sent over UDP.
+type GELFCompression int
+
+// GELFCompression.
+const (
+	GzipCompression GELFCompression = iota
+	ZlibCompression
+	NoCompression
+)
+
+var gelfCompressionNames = map[GELFCompression]string{
+	GzipCompression: "gzip",
+	ZlibCompression: "zlib",
+	NoCompression:   "none",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c GELFCompression) MarshalText() ([]byte, error) {
+	if name, ok := gelfCompressionNames[c]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown compression: %d", int(c))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *GELFCompression) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	if name == "" {
+		*c = GzipCompression
+		return nil
+	}
+	for k, v := range gelfCompressionNames {
+		if v == name {
+			*c = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown compression: %q", text)
+}
+
+// GELFEncoderConfig configures the GELF encoder.
+type GELFEncoderConfig struct {
+	// EncoderConfig configures the keys of the logger name and caller, and
+	// the encoders of the fields. The keys of the time, level, message and
+	// stacktrace are defined by GELF.
+	zapcore.EncoderConfig
+
+	// Hostname is the host of messages, it defaults to the hostname of the
+	// machine.
+	Hostname string
+	// App, if set, is added to messages as the "_app" field.
+	App string
+}
+
+type gelfEncoder struct {
+	*flatEncoder
+	cfg *GELFEncoderConfig
+}
+
+// NewGELFEncoder creates an encoder writing log entries as GELF 1.1
+// messages. Fields are sent as additional fields, with nested objects and
+// arrays flattened into dotted keys.
+func NewGELFEncoder(cfg GELFEncoderConfig) zapcore.Encoder {
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	return &gelfEncoder{
+		flatEncoder: newFlatEncoder(&cfg.EncoderConfig),
+		cfg:         &cfg,
+	}
+}
+
+func (enc *gelfEncoder) Clone() zapcore.Encoder {
+	return &gelfEncoder{
+		flatEncoder: enc.clone(),
+		cfg:         enc.cfg,
+	}
+}
+
+func (enc *gelfEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	msg := ent.Message
+	if msg == "" {
+		// short_message is mandatory and mustn't be empty
+		msg = "-"
+	}
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          enc.cfg.Hostname,
+		"short_message": msg,
+		"level":         syslogSeverity(ent.Level),
+	}
+	if !ent.Time.IsZero() {
+		// the time of receipt is used otherwise
+		m["timestamp"] = json.Number(fmt.Sprintf("%d.%06d", ent.Time.Unix(), ent.Time.Nanosecond()/1000))
+	}
+	if enc.cfg.StacktraceKey != "" && ent.Stack != "" {
+		m["full_message"] = ent.Stack
+	}
+	if enc.cfg.App != "" {
+		m["_app"] = enc.cfg.App
+	}
+
+	final := newFlatEncoder(&enc.cfg.EncoderConfig)
+	if enc.cfg.NameKey != "" && ent.LoggerName != "" {
+		var values primitiveValues
+		if enc.cfg.EncodeName != nil {
+			enc.cfg.EncodeName(ent.LoggerName, &values)
+		}
+		final.add(enc.cfg.NameKey, values.first(ent.LoggerName))
+	}
+	if enc.cfg.CallerKey != "" && ent.Caller.Defined {
+		var values primitiveValues
+		if enc.cfg.EncodeCaller != nil {
+			enc.cfg.EncodeCaller(ent.Caller, &values)
+		}
+		final.add(enc.cfg.CallerKey, values.first(ent.Caller.String()))
+	}
+	final.fields = append(final.fields, enc.fields...)
+	final.prefix = enc.prefix
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+
+	for _, f := range final.fields {
+		m[gelfFieldKey(f.Key)] = gelfFieldValue(f.Value)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	buf := gelfPool.Get()
+	buf.Write(b)
+	return buf, nil
+}
+
+// gelfFieldKey returns the key of the additional field, the characters not
+// allowed are replaced with underscores.
+func gelfFieldKey(key string) string {
+	if key == "id" {
+		// "_id" is reserved by Graylog
+		return "__id"
+	}
+
+	b := make([]byte, 0, len(key)+1)
+	b = append(b, '_')
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.', c == '-':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
+
+// gelfFieldValue returns the value of the additional field, which is either
+// a string or a number.
+func gelfFieldValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64, uint64, string:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatFlatValue(v)
+		}
+		return v
+	}
+	return formatFlatValue(v)
+}
+
+// gelfUDPSyncer sends each write as a GELF message over UDP, compressed and
+// split into chunks if needed.
+type gelfUDPSyncer struct {
+	mu          sync.Mutex
+	conn        *connSyncer
+	compression GELFCompression
+	buf         bytes.Buffer
+	// chunks are the chunks of a message, sliced from chunkData.
+	chunks    [][]byte
+	chunkData []byte
+}
+
+func newGELFUDPSyncer(network, raddr string, compression GELFCompression, buffer BufferConfig, stats *loggerStats) *gelfUDPSyncer {
+	return &gelfUDPSyncer{
+		conn:        newConnSyncer(network, raddr, buffer, stats),
+		compression: compression,
+	}
+}
+
+func (s *gelfUDPSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.compress(p)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) <= gelfChunkSize {
+		if _, err := s.conn.Write(data); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	const chunkDataSize = gelfChunkSize - gelfChunkHeaderSize
+	count := (len(data) + chunkDataSize - 1) / chunkDataSize
+	if count > gelfMaxChunks {
+		return 0, errGELFMessageTooBig
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return 0, err
+	}
+	// the chunks are buffered as a whole, Graylog can't reassemble partial
+	// messages
+	if n := len(data) + count*gelfChunkHeaderSize; cap(s.chunkData) < n {
+		s.chunkData = make([]byte, 0, n)
+	}
+	s.chunkData = s.chunkData[:0]
+	s.chunks = s.chunks[:0]
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkDataSize
+		if end > len(data) {
+			end = len(data)
+		}
+		start := len(s.chunkData)
+		s.chunkData = append(s.chunkData, 0x1e, 0x0f)
+		s.chunkData = append(s.chunkData, id[:]...)
+		s.chunkData = append(s.chunkData, byte(i), byte(count))
+		s.chunkData = append(s.chunkData, data[i*chunkDataSize:end]...)
+		s.chunks = append(s.chunks, s.chunkData[start:])
+	}
+	if err := s.conn.writeParts(s.chunks...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *gelfUDPSyncer) compress(p []byte) ([]byte, error) {
+	var w io.WriteCloser
+	s.buf.Reset()
+	switch s.compression {
+	case GzipCompression:
+		w = gzip.NewWriter(&s.buf)
+	case ZlibCompression:
+		w = zlib.NewWriter(&s.buf)
+	default:
+		return p, nil
+	}
+
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return s.buf.Bytes(), nil
+}
+
+// Sync implements zapcore.WriteSyncer interface.
+func (s *gelfUDPSyncer) Sync() error {
+	return s.conn.Sync()
+}
+
+// Close closes the connection.
+func (s *gelfUDPSyncer) Close() error {
+	return s.conn.Close()
+}
+
+// nullFramedSyncer terminates each write with a null byte, as GELF messages
+// sent over TCP.
+type nullFramedSyncer struct {
+	mu   sync.Mutex
+	conn *connSyncer
+	buf  []byte
+}
+
+func (s *nullFramedSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(append(s.buf[:0], p...), 0)
+	if _, err := s.conn.Write(s.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer interface.
+func (s *nullFramedSyncer) Sync() error {
+	return s.conn.Sync()
+}
+
+// Close closes the connection.
+func (s *nullFramedSyncer) Close() error {
+	return s.conn.Close()
+}
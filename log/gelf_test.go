@@ -0,0 +1,267 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGELFEncoder(t *testing.T) {
+	cfg := defaultGELFEncoderConfig
+	cfg.Hostname = "web1"
+	cfg.App = "test"
+	enc := NewGELFEncoder(cfg)
+	enc.AddString("id", "abc")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Unix(1514862245, 123456789),
+		LoggerName: "main",
+		Message:    "failed",
+		Stack:      "goroutine 1",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.Int("n", 42),
+		zap.Bool("ok", true),
+		zap.String("a key", "v"),
+		zap.Object("user", logfmtUser{Name: "jane", Tags: []string{"x"}}),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var m map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &m)) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web1",
+		"short_message": "failed",
+		"full_message":  "goroutine 1",
+		"timestamp":     1514862245.123456,
+		"level":         float64(3),
+		"_app":          "test",
+		"_logger":       "main",
+		"__id":          "abc",
+		"_n":            float64(42),
+		"_ok":           "true",
+		"_a_key":        "v",
+		"_user.name":    "jane",
+		"_user.tags.0":  "x",
+	}, m)
+}
+
+func readGELFChunks(t *testing.T, conn net.PacketConn) []byte {
+	var data []byte
+	b := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(b)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		if n < 2 || b[0] != 0x1e || b[1] != 0x0f {
+			return append(data, b[:n]...)
+		}
+		assert.True(t, n <= gelfChunkSize)
+		data = append(data, b[gelfChunkHeaderSize:n]...)
+		if int(b[10]) == int(b[11])-1 {
+			return data
+		}
+	}
+}
+
+func TestGELFUDPSyncer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// random content is too big for a single datagram even when compressed
+	random := make([]byte, 3*gelfChunkSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	large := []byte(hex.EncodeToString(random))
+
+	fixtures := []struct {
+		compression GELFCompression
+		message     []byte
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{NoCompression, []byte(`{"short_message":"hi"}`), nil},
+		{NoCompression, large, nil},
+		{GzipCompression, large, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{ZlibCompression, large, func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+	}
+
+	for i, f := range fixtures {
+		s := newGELFUDPSyncer("udp", conn.LocalAddr().String(), f.compression, defaultBufferConfig, nil)
+		n, err := s.Write(f.message)
+		assert.NoError(t, err)
+		assert.Equal(t, len(f.message), n)
+		assert.NoError(t, s.Close())
+
+		data := readGELFChunks(t, conn)
+		if f.decompress != nil {
+			r, err := f.decompress(bytes.NewReader(data))
+			if !assert.NoError(t, err, "at index %d", i) {
+				return
+			}
+			data, err = ioutil.ReadAll(r)
+			assert.NoError(t, err, "at index %d", i)
+		}
+		assert.Equal(t, string(f.message), string(data), "at index %d", i)
+	}
+}
+
+func TestConfigOpenGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := ioutil.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	c, err := ParseConfigFromURIString("logger:gelf?hostname=web1&outputAddress=tcp:" + ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("one")
+	l.Warn("two")
+	assert.NoError(t, l.Close())
+
+	select {
+	case s := <-received:
+		messages := strings.Split(s, "\x00")
+		if assert.Len(t, messages, 3) {
+			assert.Contains(t, messages[0], `"short_message":"one"`)
+			assert.Contains(t, messages[1], `"level":4`)
+			assert.Equal(t, "", messages[2])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for messages")
+	}
+}
+
+func TestConfigOpenGELFErrorOutputPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gelf")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	path := filepath.Join(dir, "err.log")
+	lumberPath := filepath.Join(dir, "err.lumber.log")
+	c, err := ParseConfigFromURIString("logger:gelf?compression=none&outputAddress=udp:" + conn.LocalAddr().String() +
+		"&errorOutputPath=" + path + "&errorLumberjack=filename=" + lumberPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{path}, c.ErrorOutputPaths)
+	if assert.Len(t, c.ErrorLumberjacks, 1) {
+		assert.Equal(t, lumberPath, c.ErrorLumberjacks[0].Filename)
+	}
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// too many chunks, the write error goes to the error outputs
+	l.Info(strings.Repeat("x", 2<<20))
+	assert.NoError(t, l.Close())
+
+	for _, p := range []string{path, lumberPath} {
+		b, err := ioutil.ReadFile(p)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(b), errGELFMessageTooBig.Error(), p)
+		}
+	}
+}
+
+func TestGELFUDPSyncerDropPolicy(t *testing.T) {
+	fixtures := []struct {
+		buffer   BufferConfig
+		expected string
+	}{
+		{BufferConfig{Size: 2, DropPolicy: DropOldest}, "acd"},
+		{BufferConfig{Size: 2, DropPolicy: DropNewest}, "abc"},
+		// room for the chunks of two messages but one
+		{BufferConfig{Bytes: 5 * gelfChunkSize, DropPolicy: DropOldest}, "acd"},
+	}
+
+	for i, f := range fixtures {
+		d := newPipeDialer(0, false)
+		stats := &loggerStats{}
+		s := &gelfUDPSyncer{
+			conn:        newDialConnSyncer("udp", "graylog", d.dial, f.buffer, stats),
+			compression: NoCompression,
+		}
+
+		// each message is split into 3 chunks, "a" is taken by the writer,
+		// which is dialing
+		for _, c := range "abcd" {
+			s.Write(bytes.Repeat([]byte{byte(c)}, 2*gelfChunkSize))
+			if c == 'a' {
+				<-d.dialing
+			}
+		}
+		close(d.release)
+
+		server := <-d.conns
+		var received []byte
+		b := make([]byte, gelfChunkSize)
+		for len(received) < 9*len(f.expected) {
+			server.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, err := server.Read(b)
+			if !assert.NoError(t, err, "at index %d", i) || !assert.True(t, n > gelfChunkHeaderSize, "at index %d", i) {
+				break
+			}
+			// the sequence number, the count and the first byte of the data
+			received = append(received, '0'+b[10], '0'+b[11], b[12])
+		}
+
+		var expected []byte
+		for _, c := range []byte(f.expected) {
+			expected = append(expected, '0', '3', c, '1', '3', c, '2', '3', c)
+		}
+		assert.Equal(t, string(expected), string(received), "at index %d", i)
+		assert.Equal(t, uint64(1), stats.snapshot().Dropped, "at index %d", i)
+		s.Close()
+	}
+}
@@ -1,13 +1,18 @@
 package log
 
 import (
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
 	globalMu     sync.RWMutex
+	globalOwned  *Logger
 	globalL      *zap.Logger
 	globalQuickL *zap.Logger
 	globalS      *zap.SugaredLogger
@@ -24,20 +29,74 @@ func init() {
 	}
 }
 
-func initGlobalLogger(format string) error {
-	c, err := ParseConfigFromURIString(format)
+func initGlobalLogger(formats ...string) error {
+	cs, level, err := parseLogFormats(formats...)
 	if err != nil {
 		return err
 	}
 
-	l, err := c.Build()
+	l, err := cs.Open()
 	if err != nil {
 		return err
 	}
-	ReplaceGlobals(l)
+	replaceOwnedGlobals(l)
+	if level != nil {
+		baseLoggerLevel.SetLevel(*level)
+	}
 	return nil
 }
 
+// parseLogFormats parses the values of the log.format flag, each of them is
+// either a logger URI, or a config file path prefixed with "@". It returns
+// the level set by the config files, if any, see installFileLevels.
+func parseLogFormats(formats ...string) (Configs, *zapcore.Level, error) {
+	var cs Configs
+	var level *zapcore.Level
+	for _, format := range formats {
+		if strings.HasPrefix(format, "@") {
+			fileConfigs, err := LoadConfigsFile(format[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			if l := installFileLevels(fileConfigs); l != nil {
+				level = l
+			}
+			cs = append(cs, fileConfigs...)
+			continue
+		}
+
+		c, err := ParseConfigFromURIString(format)
+		if err != nil {
+			return nil, nil, err
+		}
+		cs = append(cs, *c)
+	}
+	return cs, level, nil
+}
+
+// replacedLoggerGrace is the time the sinks of a replaced logger are kept
+// open for, since the goroutines holding it may still log to it.
+var replacedLoggerGrace = 5 * time.Second
+
+// replaceOwnedGlobals replaces the globals with a logger built by this
+// package, and releases the sinks of the previous one built the same way:
+// it's synced right away, and closed after replacedLoggerGrace.
+func replaceOwnedGlobals(l *Logger) {
+	ReplaceGlobals(l.Logger)
+
+	globalMu.Lock()
+	prev := globalOwned
+	globalOwned = l
+	globalMu.Unlock()
+
+	if prev != nil {
+		prev.Sync()
+		time.AfterFunc(replacedLoggerGrace, func() {
+			prev.Close()
+		})
+	}
+}
+
 // L returns the global zap.Logger.
 //
 // It's safe for concurrent use.
@@ -59,11 +118,115 @@ func S() *zap.SugaredLogger {
 	return s
 }
 
+// CurrentConfig returns the configs of the targets behind the current global
+// logger, which is the one built from the log.format flag. It returns nil if
+// the global logger was replaced by a logger built elsewhere.
+//
+// It's safe for concurrent use.
+func CurrentConfig() Configs {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+
+	if globalOwned == nil || globalOwned.Logger != globalL {
+		return nil
+	}
+	return globalOwned.Configs()
+}
+
+// globalCore is a zapcore.Core writing to the core of the current global
+// logger, so the handlers built on it keep up with ReplaceGlobals.
+type globalCore struct {
+	fields []zapcore.Field
+	// derived holds the *derivedCore of the last global logger seen.
+	derived atomic.Value
+}
+
+// derivedCore is the core of the global logger with the fields of a
+// globalCore.
+type derivedCore struct {
+	base *zap.Logger
+	core zapcore.Core
+}
+
+func (c *globalCore) core() zapcore.Core {
+	base := L()
+	if d, _ := c.derived.Load().(*derivedCore); d != nil && d.base == base {
+		return d.core
+	}
+
+	core := base.Core()
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	c.derived.Store(&derivedCore{base: base, core: core})
+	return core
+}
+
+func (c *globalCore) Enabled(l zapcore.Level) bool {
+	return c.core().Enabled(l)
+}
+
+func (c *globalCore) With(fields []zapcore.Field) zapcore.Core {
+	return &globalCore{fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+func (c *globalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.core().Check(ent, ce)
+}
+
+func (c *globalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.core().Write(ent, fields)
+}
+
+func (c *globalCore) Sync() error {
+	return c.core().Sync()
+}
+
+// GlobalsOption configures ReplaceGlobals.
+type GlobalsOption func(*globalsOptions)
+
+type globalsOptions struct {
+	slog        *SlogHandlerOptions
+	stdLogLevel *zapcore.Level
+}
+
+// WithSlogDefault makes ReplaceGlobals set the default logger of log/slog as
+// well, with a handler writing to the core of the global logger, so both APIs
+// log to the same sinks with the same format and level, even after the
+// global logger is replaced again, e.g. by WatchConfig. It has no effect
+// before Go 1.21.
+func WithSlogDefault(opts SlogHandlerOptions) GlobalsOption {
+	return func(o *globalsOptions) {
+		o.slog = &opts
+	}
+}
+
+// SlogHandlerOptions are the options of the slog handlers built by this
+// package.
+type SlogHandlerOptions struct {
+	// AddSource annotates the entries with the callers of the slog functions.
+	AddSource bool
+	// AddStacktrace, if set, records the stacktraces of the callers of the
+	// slog functions for the entries at the levels it enables, like
+	// zap.AddStacktrace.
+	AddStacktrace zapcore.LevelEnabler
+}
+
+// replaceSlogDefault replaces the default logger of log/slog with one writing
+// to the global logger, and returns a function to restore it. It's nil before
+// Go 1.21.
+var replaceSlogDefault func(opts SlogHandlerOptions) func()
+
 // ReplaceGlobals replaces the global zap.Logger and the zap.SugaredLogger, and returns
 // a function to restore the original values.
 //
 // It's safe for concurrent use.
-func ReplaceGlobals(logger *zap.Logger) func() {
+func ReplaceGlobals(logger *zap.Logger, opts ...GlobalsOption) func() {
+	var o globalsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	globalMu.Lock()
 	prev := globalL
 	globalL = logger
@@ -74,7 +237,19 @@ func ReplaceGlobals(logger *zap.Logger) func() {
 	// Replace zap's global logger as well
 	restoreZapLogger := zap.ReplaceGlobals(logger)
 
+	restoreSlog := func() {}
+	if o.slog != nil && replaceSlogDefault != nil {
+		restoreSlog = replaceSlogDefault(*o.slog)
+	}
+	// after slog, which redirects the standard logger too
+	restoreStdLog := func() {}
+	if o.stdLogLevel != nil {
+		restoreStdLog = redirectStdLog(*o.stdLogLevel)
+	}
+
 	return func() {
+		restoreStdLog()
+		restoreSlog()
 		restoreZapLogger()
 		ReplaceGlobals(prev)
 	}
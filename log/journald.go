@@ -0,0 +1,134 @@
+package log
+
+import (
+	"encoding/binary"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultJournaldSocket is the socket of the native journal protocol.
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journaldMaxKeyLength is the maximum length of journal field names.
+const journaldMaxKeyLength = 64
+
+var journaldPool = buffer.NewPool()
+
+// JournaldEncoderConfig configures the journald encoder.
+type JournaldEncoderConfig struct {
+	// EncoderConfig configures the keys of the logger name and stacktrace,
+	// and the encoders of the fields. The keys are converted to journal
+	// field names, e.g. "logger" to "LOGGER".
+	zapcore.EncoderConfig
+
+	// App, if set, is the SYSLOG_IDENTIFIER of entries.
+	App string
+}
+
+type journaldEncoder struct {
+	*flatEncoder
+	cfg *JournaldEncoderConfig
+}
+
+// NewJournaldEncoder creates an encoder writing log entries in the native
+// journal protocol. Fields are converted to uppercase journal fields, with
+// nested objects and arrays flattened, e.g. "user.name" to "USER_NAME".
+func NewJournaldEncoder(cfg JournaldEncoderConfig) zapcore.Encoder {
+	return &journaldEncoder{
+		flatEncoder: newFlatEncoder(&cfg.EncoderConfig),
+		cfg:         &cfg,
+	}
+}
+
+func (enc *journaldEncoder) Clone() zapcore.Encoder {
+	return &journaldEncoder{
+		flatEncoder: enc.clone(),
+		cfg:         enc.cfg,
+	}
+}
+
+func (enc *journaldEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf := journaldPool.Get()
+	appendJournaldField(buf, "MESSAGE", ent.Message)
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(ent.Level)))
+	if enc.cfg.App != "" {
+		appendJournaldField(buf, "SYSLOG_IDENTIFIER", enc.cfg.App)
+	}
+	if ent.Caller.Defined {
+		appendJournaldField(buf, "CODE_FILE", ent.Caller.File)
+		appendJournaldField(buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if fn := runtime.FuncForPC(ent.Caller.PC); fn != nil {
+			appendJournaldField(buf, "CODE_FUNC", fn.Name())
+		}
+	}
+
+	final := newFlatEncoder(&enc.cfg.EncoderConfig)
+	if enc.cfg.NameKey != "" && ent.LoggerName != "" {
+		var values primitiveValues
+		if enc.cfg.EncodeName != nil {
+			enc.cfg.EncodeName(ent.LoggerName, &values)
+		}
+		final.add(enc.cfg.NameKey, values.first(ent.LoggerName))
+	}
+	final.fields = append(final.fields, enc.fields...)
+	final.prefix = enc.prefix
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.prefix = ""
+	if enc.cfg.StacktraceKey != "" && ent.Stack != "" {
+		final.add(enc.cfg.StacktraceKey, ent.Stack)
+	}
+
+	for _, f := range final.fields {
+		if key := journaldFieldKey(f.Key); key != "" {
+			appendJournaldField(buf, key, formatFlatValue(f.Value))
+		}
+	}
+	return buf, nil
+}
+
+// appendJournaldField appends a field in the native journal protocol, the
+// value is length-prefixed if it contains newlines.
+func appendJournaldField(buf *buffer.Buffer, key, value string) {
+	buf.AppendString(key)
+	if !strings.ContainsRune(value, '\n') {
+		buf.AppendByte('=')
+		buf.AppendString(value)
+		buf.AppendByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.AppendByte('\n')
+	buf.Write(size[:])
+	buf.AppendString(value)
+	buf.AppendByte('\n')
+}
+
+// journaldFieldKey converts the key into a journal field name, which only
+// consists of uppercase letters, digits and underscores, and doesn't start
+// with an underscore or a digit. It returns "" if nothing is left.
+func journaldFieldKey(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < journaldMaxKeyLength; i++ {
+		c := key[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		default:
+			c = '_'
+		}
+		if len(b) == 0 && (c == '_' || c >= '0' && c <= '9') {
+			continue
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
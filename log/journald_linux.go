@@ -0,0 +1,104 @@
+// +build linux
+
+package log
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/unix"
+)
+
+var _ zapcore.WriteSyncer = &journaldSyncer{}
+
+// journaldSyncer sends each write as a journal entry over the unix datagram
+// socket of journald. Entries too large for a datagram are sent as memfds.
+type journaldSyncer struct {
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	addr   *net.UnixAddr
+	closed bool
+}
+
+func newJournaldSyncer(path string) (*journaldSyncer, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldSyncer{
+		conn: conn,
+		addr: &net.UnixAddr{Name: path, Net: "unixgram"},
+	}, nil
+}
+
+func (s *journaldSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errSinkClosed
+	}
+
+	_, _, err := s.conn.WriteMsgUnix(p, nil, s.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !isMessageTooLarge(err) {
+		return 0, err
+	}
+
+	if err := s.writeMemfd(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeMemfd writes the entry into a sealed memfd, and sends its descriptor
+// to journald.
+func (s *journaldSyncer) writeMemfd(p []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	f := os.NewFile(uintptr(fd), "journal-entry")
+	defer f.Close()
+
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		return err
+	}
+
+	_, _, err = s.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), s.addr)
+	return err
+}
+
+// Sync implements zapcore.WriteSyncer interface.
+func (s *journaldSyncer) Sync() error {
+	return nil
+}
+
+// Close closes the socket.
+func (s *journaldSyncer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return s.conn.Close()
+}
+
+func isMessageTooLarge(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.EMSGSIZE || err == syscall.ENOBUFS
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readJournaldEntry reads an entry sent to the listener, either as a
+// datagram or as a memfd.
+func readJournaldEntry(t *testing.T, conn *net.UnixConn) string {
+	b := make([]byte, 65536)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	if oobn == 0 {
+		return string(b[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if !assert.NoError(t, err) || !assert.Len(t, msgs, 1) {
+		return ""
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if !assert.NoError(t, err) || !assert.Len(t, fds, 1) {
+		return ""
+	}
+	f := os.NewFile(uintptr(fds[0]), "memfd")
+	defer f.Close()
+	if _, err := f.Seek(0, 0); !assert.NoError(t, err) {
+		return ""
+	}
+	data, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestConfigOpenJournald(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	c, err := ParseConfigFromURIString("logger:journald?app=test&disableCaller=false&outputAddress=" + socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	l.Info("hello")
+	entry := readJournaldEntry(t, conn)
+	assert.Contains(t, entry, "MESSAGE=hello\nPRIORITY=6\nSYSLOG_IDENTIFIER=test\nCODE_FILE=")
+	assert.Contains(t, entry, "CODE_FUNC=github.com/imperfectgo/common/log.TestConfigOpenJournald\n")
+
+	// too large for a datagram
+	large := strings.Repeat("x", 4<<20)
+	l.Info(large)
+	entry = readJournaldEntry(t, conn)
+	assert.True(t, strings.HasPrefix(entry, "MESSAGE="+large+"\nPRIORITY=6\n"))
+}
+
+func TestConfigOpenJournaldErrorOutputPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "journal.socket")
+	path := filepath.Join(dir, "err.log")
+	lumberPath := filepath.Join(dir, "err.lumber.log")
+	c, err := ParseConfigFromURIString("logger:journald?outputAddress=" + socket +
+		"&errorOutputPath=" + path + "&errorLumberjack=filename=" + lumberPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{path}, c.ErrorOutputPaths)
+	if assert.Len(t, c.ErrorLumberjacks, 1) {
+		assert.Equal(t, lumberPath, c.ErrorLumberjacks[0].Filename)
+	}
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// nothing listens on the socket, the write error goes to the error outputs
+	l.Info("lost")
+	assert.NoError(t, l.Close())
+
+	for _, p := range []string{path, lumberPath} {
+		b, err := ioutil.ReadFile(p)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(b), "write error", p)
+		}
+	}
+}
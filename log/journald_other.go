@@ -0,0 +1,20 @@
+// +build !linux
+
+package log
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+type journaldSyncer struct {
+	zapcore.WriteSyncer
+}
+
+func newJournaldSyncer(path string) (*journaldSyncer, error) {
+	return nil, errors.New("log: journald is only supported on linux")
+}
+
+func (s *journaldSyncer) Close() error {
+	return nil
+}
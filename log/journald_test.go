@@ -0,0 +1,63 @@
+package log
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJournaldEncoder(t *testing.T) {
+	cfg := defaultJournaldEncoderConfig
+	cfg.App = "test"
+	enc := NewJournaldEncoder(cfg)
+	enc.AddString("request.id", "abc")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		LoggerName: "main",
+		Message:    "hello",
+		Caller:     zapcore.NewEntryCaller(0, "/src/main.go", 42, true),
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.Int("n", 1),
+		zap.String("_private", "x"),
+		zap.String("multi", "a\nb"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, 3)
+	expected := strings.Join([]string{
+		"MESSAGE=hello",
+		"PRIORITY=4",
+		"SYSLOG_IDENTIFIER=test",
+		"CODE_FILE=/src/main.go",
+		"CODE_LINE=42",
+		"LOGGER=main",
+		"REQUEST_ID=abc",
+		"N=1",
+		"PRIVATE=x",
+		"MULTI\n" + string(size) + "a\nb",
+	}, "\n") + "\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestJournaldFieldKey(t *testing.T) {
+	fixtures := map[string]string{
+		"user.name": "USER_NAME",
+		"__id":      "ID",
+		"1st":       "ST",
+		"_":         "",
+		"A-b":       "A_B",
+	}
+	for key, expected := range fixtures {
+		assert.Equal(t, expected, journaldFieldKey(key), "key %q", key)
+	}
+	assert.Len(t, journaldFieldKey(strings.Repeat("a", 100)), journaldMaxKeyLength)
+}
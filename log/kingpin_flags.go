@@ -1,8 +1,6 @@
 package log
 
 import (
-	"net/url"
-
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -10,37 +8,45 @@ import (
 // To use the default Kingpin application, call AddFlags(kingpin.CommandLine)
 func AddKingpinFlags(a *kingpin.Application) {
 	s := loggerSettings{}
-	a.Flag("log.level", "Only log messages with the given severity or above. Valid levels: [debug, info, warn, error, dpanic, panic, fatal]").
+	a.Flag("log.level", levelFlagHelp).
 		Default(baseLoggerLevel.String()).
 		StringVar(&s.level)
-	a.Flag("log.format", `Set the log target and format. Example: "logger:console?disableCaller=false&development=true&outputPaths=stdout&errorOutputPaths=stderr" or "logger:json?disableStacktrace=true"`).
+	a.Flag("log.format", `Set the log target and format, repeat it to log to multiple targets. Example: "logger:console?disableCaller=false&development=true&outputPaths=stdout&errorOutputPaths=stderr" or "logger:json?disableStacktrace=true", prefix a path with "@" to load config from a YAML/JSON file`).
 		Default(defaultLogFormatURI).
-		URLVar(&s.format)
+		StringsVar(&s.formats)
 	a.Action(s.apply)
 }
 
 type loggerSettings struct {
-	level  string
-	format *url.URL
+	level   string
+	formats []string
 }
 
-func (s *loggerSettings) apply(ctx *kingpin.ParseContext) (err error) {
-	c, err := ParseConfigFromURI(s.format)
+func (s *loggerSettings) apply(ctx *kingpin.ParseContext) error {
+	// Parse the level up front, the previous logger is released once the new
+	// one is installed, so there is nothing to restore afterwards.
+	level, levels, err := parseLevelRules(s.level)
 	if err != nil {
 		return err
 	}
 
-	l, err := c.Build()
+	cs, fileLevel, err := parseLogFormats(s.formats...)
 	if err != nil {
 		return err
 	}
 
-	restore := ReplaceGlobals(l)
-	defer func() {
-		if err != nil {
-			restore()
-		}
-	}()
+	l, err := cs.Open()
+	if err != nil {
+		return err
+	}
 
-	return baseLoggerLevel.UnmarshalText([]byte(s.level))
+	replaceOwnedGlobals(l)
+	if fileLevel != nil {
+		baseLoggerLevel.SetLevel(*fileLevel)
+	}
+	if level != nil {
+		baseLoggerLevel.SetLevel(*level)
+	}
+	setLoggerLevels(levels)
+	return nil
 }
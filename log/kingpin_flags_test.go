@@ -22,3 +22,22 @@ func TestAddKingpinFlags(t *testing.T) {
 
 	assert.Equal(t, "debug", baseLoggerLevel.String())
 }
+
+func TestAddKingpinFlagsMultipleTargets(t *testing.T) {
+	app := newTestApp()
+	AddKingpinFlags(app)
+
+	_, err := app.Parse([]string{
+		"--log.format", "logger:console?outputPaths=stderr",
+		"--log.format", "logger:json?outputPaths=stdout",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cs := CurrentConfig()
+	if assert.Len(t, cs, 2) {
+		assert.Equal(t, ConsoleEncoder, cs[0].EncoderType)
+		assert.Equal(t, JSONEncoder, cs[1].EncoderType)
+	}
+}
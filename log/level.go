@@ -0,0 +1,64 @@
+package log
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// levelRange is a zapcore.LevelEnabler, which enables the levels within
+// [min, max] that are also enabled by the underlying enabler.
+type levelRange struct {
+	zapcore.LevelEnabler
+
+	min zapcore.Level
+	max zapcore.Level
+}
+
+func newLevelRange(enab zapcore.LevelEnabler, min, max *zapcore.Level) zapcore.LevelEnabler {
+	if min == nil && max == nil {
+		return enab
+	}
+
+	r := &levelRange{
+		LevelEnabler: enab,
+		min:          zapcore.DebugLevel,
+		max:          zapcore.FatalLevel,
+	}
+	if min != nil {
+		r.min = *min
+	}
+	if max != nil {
+		r.max = *max
+	}
+	return r
+}
+
+func (r *levelRange) Enabled(lvl zapcore.Level) bool {
+	return lvl >= r.min && lvl <= r.max && r.LevelEnabler.Enabled(lvl)
+}
+
+func parseLevel(s string) (*zapcore.Level, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// syslogSeverity maps the level to its syslog severity.
+func syslogSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return 2
+	case zapcore.FatalLevel:
+		return 0
+	}
+	return 6
+}
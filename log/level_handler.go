@@ -0,0 +1,194 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var globalLevelHandler = newLevelHandler(baseLoggerLevel)
+
+// LevelHandler returns an HTTP handler of the level of the loggers built from
+// the log.level flag, which can sit next to debugutil.PProfHandlers.
+//
+// GET requests return the current level, e.g. {"level":"info"}. PUT requests
+// change the level, with a payload like {"level":"debug","duration":"10m"},
+// or the same "level" and "duration" parameters in the query or form. The
+// level is reverted automatically after the duration, if given. Every change
+// is audited as a log entry.
+func LevelHandler() http.Handler {
+	return globalLevelHandler
+}
+
+type levelPayload struct {
+	Level    *zapcore.Level `json:"level"`
+	Duration string         `json:"duration,omitempty"`
+	// RevertTo and RevertAt describe the pending revert of a timed change.
+	RevertTo *zapcore.Level `json:"revertTo,omitempty"`
+	RevertAt *time.Time     `json:"revertAt,omitempty"`
+}
+
+type levelHandler struct {
+	level zap.AtomicLevel
+
+	mu sync.Mutex
+	// timer reverts the level to revertTo at revertAt, it's nil if there is
+	// no timed change.
+	timer    *time.Timer
+	revertTo zapcore.Level
+	revertAt time.Time
+	// timers counts the timers, to tell the superseded ones.
+	timers int
+}
+
+func newLevelHandler(level zap.AtomicLevel) *levelHandler {
+	return &levelHandler{level: level}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		enc.Encode(h.payload())
+
+	case "PUT":
+		level, duration, err := parseLevelRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(errorResponse{Error: err.Error()})
+			return
+		}
+
+		h.set(level, duration, r.RemoteAddr)
+		enc.Encode(h.payload())
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(errorResponse{Error: "Only GET and PUT are supported."})
+	}
+}
+
+// parseLevelRequest parses the level and duration of PUT requests, from
+// the query or form, or from the JSON payload.
+func parseLevelRequest(r *http.Request) (zapcore.Level, time.Duration, error) {
+	var level zapcore.Level
+	var req levelPayload
+	if v := r.FormValue("level"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			return level, 0, err
+		}
+		req.Level = &level
+		req.Duration = r.FormValue("duration")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return level, 0, fmt.Errorf("Request body must be well-formed JSON: %v", err)
+	}
+	if req.Level == nil {
+		return level, 0, fmt.Errorf("Must specify a logging level.")
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return level, 0, err
+		}
+		if d <= 0 {
+			return level, 0, fmt.Errorf("Duration must be positive.")
+		}
+		duration = d
+	}
+	return *req.Level, duration, nil
+}
+
+func (h *levelHandler) payload() levelPayload {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	level := h.level.Level()
+	p := levelPayload{Level: &level}
+	if h.timer != nil {
+		revertTo, revertAt := h.revertTo, h.revertAt
+		p.RevertTo = &revertTo
+		p.RevertAt = &revertAt
+	}
+	return p
+}
+
+// set changes the level, it's reverted after the duration if positive. A
+// timed change keeps reverting to the level before the first one.
+func (h *levelHandler) set(level zapcore.Level, duration time.Duration, remoteAddr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	revertTo := h.level.Level()
+	if h.timer != nil {
+		h.timer.Stop()
+		revertTo = h.revertTo
+		h.timer = nil
+	}
+
+	fields := []zapcore.Field{zap.String("remoteAddr", remoteAddr)}
+	if duration > 0 {
+		fields = append(fields, zap.Duration("duration", duration))
+
+		h.timers++
+		n := h.timers
+		h.timer = time.AfterFunc(duration, func() { h.revert(n) })
+		h.revertTo = revertTo
+		h.revertAt = time.Now().Add(duration)
+	}
+	h.change(level, "log level changed", fields...)
+}
+
+// revert reverts the timed change of the n-th timer, unless it was
+// superseded.
+func (h *levelHandler) revert(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.timer == nil || h.timers != n {
+		return
+	}
+	h.timer = nil
+	h.change(h.revertTo, "log level reverted")
+}
+
+// change changes the level and audits it. The entry is logged at the lower of
+// the levels, while that one is in effect, so the change itself never
+// filters it out.
+func (h *levelHandler) change(level zapcore.Level, msg string, fields ...zapcore.Field) {
+	from := h.level.Level()
+	if level < from {
+		h.level.SetLevel(level)
+	}
+
+	auditLevel := from
+	if level < auditLevel {
+		auditLevel = level
+	}
+	if auditLevel < zapcore.InfoLevel {
+		auditLevel = zapcore.InfoLevel
+	}
+	if auditLevel > zapcore.ErrorLevel {
+		// don't panic or exit
+		auditLevel = zapcore.ErrorLevel
+	}
+	if ce := L().Check(auditLevel, msg); ce != nil {
+		ce.Write(append(fields, zap.Stringer("from", from), zap.Stringer("to", level))...)
+	}
+
+	if level >= from {
+		h.level.SetLevel(level)
+	}
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	level := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.Lock(zapcore.AddSync(&buf)), level)
+	defer ReplaceGlobals(zap.New(core))()
+
+	h := newLevelHandler(level)
+	serve := func(method, target, body string) (int, levelPayload) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var p levelPayload
+		json.Unmarshal(rec.Body.Bytes(), &p)
+		return rec.Code, p
+	}
+
+	code, p := serve("GET", "/", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, zapcore.WarnLevel, *p.Level)
+
+	code, p = serve("PUT", "/", `{"level":"error"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, zapcore.ErrorLevel, level.Level())
+	assert.Nil(t, p.RevertTo)
+
+	code, p = serve("PUT", "/?level=debug&duration=50ms", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, zapcore.DebugLevel, *p.Level)
+	if assert.NotNil(t, p.RevertTo) {
+		assert.Equal(t, zapcore.ErrorLevel, *p.RevertTo)
+	}
+
+	for i := 0; i < 100 && level.Level() != zapcore.ErrorLevel; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, zapcore.ErrorLevel, level.Level())
+
+	code, _ = serve("PUT", "/", `{"duration":"1m"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serve("PUT", "/?level=info&duration=-1s", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = serve("POST", "/", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"level":"warn","msg":"log level changed"`)
+		assert.Contains(t, lines[0], `"from":"warn","to":"error"`)
+		assert.Contains(t, lines[1], `"duration":0.05,"from":"error","to":"debug"`)
+		assert.Contains(t, lines[2], `"msg":"log level reverted","from":"debug","to":"error"`)
+	}
+}
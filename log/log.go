@@ -1,6 +1,8 @@
 package log
 
 import (
+	"context"
+
 	"go.uber.org/zap/zapcore"
 )
 
@@ -53,3 +55,66 @@ func Panic(msg string, fields ...zapcore.Field) {
 func Fatal(msg string, fields ...zapcore.Field) {
 	globalQuickL.Fatal(msg, fields...)
 }
+
+// DebugCtx is like Debug, but logs with the logger carried by ctx, see
+// FromContext.
+func DebugCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// InfoCtx is like Info, but logs with the logger carried by ctx, see
+// FromContext.
+func InfoCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// WarnCtx is like Warn, but logs with the logger carried by ctx, see
+// FromContext.
+func WarnCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// ErrorCtx is like Error, but logs with the logger carried by ctx, see
+// FromContext.
+func ErrorCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// DPanicCtx is like DPanic, but logs with the logger carried by ctx, see
+// FromContext.
+func DPanicCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.DPanicLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// PanicCtx is like Panic, but logs with the logger carried by ctx, see
+// FromContext.
+func PanicCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
+
+// FatalCtx is like Fatal, but logs with the logger carried by ctx, see
+// FromContext.
+func FatalCtx(ctx context.Context, msg string, fields ...zapcore.Field) {
+	logger, carried := quickCtxL(ctx)
+	if ce := logger.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(ctxFields(ctx, carried, fields)...)
+	}
+}
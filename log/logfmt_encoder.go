@@ -0,0 +1,175 @@
+package log
+
+import (
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+type logfmtEncoder struct {
+	*flatEncoder
+	cfg *zapcore.EncoderConfig
+}
+
+// NewLogfmtEncoder creates an encoder writing log entries as logfmt lines of
+// key=value pairs. Nested objects and arrays are flattened into dotted keys,
+// e.g. "user.name=foo ids.0=1".
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{
+		flatEncoder: newFlatEncoder(&cfg),
+		cfg:         &cfg,
+	}
+}
+
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{
+		flatEncoder: enc.clone(),
+		cfg:         enc.cfg,
+	}
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := newFlatEncoder(enc.cfg)
+	if enc.cfg.TimeKey != "" && !ent.Time.IsZero() {
+		final.AddTime(enc.cfg.TimeKey, ent.Time)
+	}
+	if enc.cfg.LevelKey != "" {
+		var values primitiveValues
+		if enc.cfg.EncodeLevel != nil {
+			enc.cfg.EncodeLevel(ent.Level, &values)
+		}
+		final.add(enc.cfg.LevelKey, values.first(ent.Level.String()))
+	}
+	if enc.cfg.NameKey != "" && ent.LoggerName != "" {
+		var values primitiveValues
+		if enc.cfg.EncodeName != nil {
+			enc.cfg.EncodeName(ent.LoggerName, &values)
+		}
+		final.add(enc.cfg.NameKey, values.first(ent.LoggerName))
+	}
+	if enc.cfg.CallerKey != "" && ent.Caller.Defined {
+		var values primitiveValues
+		if enc.cfg.EncodeCaller != nil {
+			enc.cfg.EncodeCaller(ent.Caller, &values)
+		}
+		final.add(enc.cfg.CallerKey, values.first(ent.Caller.String()))
+	}
+	if enc.cfg.MessageKey != "" {
+		final.add(enc.cfg.MessageKey, ent.Message)
+	}
+
+	final.fields = append(final.fields, enc.fields...)
+	final.prefix = enc.prefix
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.prefix = ""
+	if enc.cfg.StacktraceKey != "" && ent.Stack != "" {
+		final.add(enc.cfg.StacktraceKey, ent.Stack)
+	}
+
+	buf := logfmtPool.Get()
+	for i, f := range final.fields {
+		if i > 0 {
+			buf.AppendByte(' ')
+		}
+		appendLogfmtKey(buf, f.Key)
+		buf.AppendByte('=')
+		appendLogfmtValue(buf, formatFlatValue(f.Value))
+	}
+	if enc.cfg.LineEnding != "" {
+		buf.AppendString(enc.cfg.LineEnding)
+	} else {
+		buf.AppendString(zapcore.DefaultLineEnding)
+	}
+	return buf, nil
+}
+
+// appendLogfmtKey appends the key, replacing the characters not allowed in
+// logfmt keys with underscores.
+func appendLogfmtKey(buf *buffer.Buffer, key string) {
+	if key == "" {
+		buf.AppendByte('_')
+		return
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			buf.AppendByte('_')
+			continue
+		}
+		buf.AppendString(string(r))
+	}
+}
+
+// appendLogfmtValue appends the value, quoted if it is empty or contains
+// spaces, quotes, equal signs or control characters.
+func appendLogfmtValue(buf *buffer.Buffer, val string) {
+	const hex = "0123456789abcdef"
+
+	if !needsLogfmtQuoting(val) {
+		buf.AppendString(val)
+		return
+	}
+
+	buf.AppendByte('"')
+	for i := 0; i < len(val); {
+		c := val[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(val[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.AppendString(`�`)
+			} else {
+				buf.AppendString(val[i : i+size])
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '\\', '"':
+			buf.AppendByte('\\')
+			buf.AppendByte(c)
+		case '\n':
+			buf.AppendString(`\n`)
+		case '\r':
+			buf.AppendString(`\r`)
+		case '\t':
+			buf.AppendString(`\t`)
+		default:
+			if c < ' ' || c == 0x7f {
+				buf.AppendString(`\u00`)
+				buf.AppendByte(hex[c>>4])
+				buf.AppendByte(hex[c&0xf])
+			} else {
+				buf.AppendByte(c)
+			}
+		}
+		i++
+	}
+	buf.AppendByte('"')
+}
+
+func needsLogfmtQuoting(val string) bool {
+	if val == "" {
+		return true
+	}
+	for i := 0; i < len(val); {
+		c := val[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(val[i:])
+			if r == utf8.RuneError && size == 1 {
+				return true
+			}
+			i += size
+			continue
+		}
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+		i++
+	}
+	return false
+}
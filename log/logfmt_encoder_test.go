@@ -0,0 +1,80 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logfmtUser struct {
+	Name string
+	Tags []string
+}
+
+func (u logfmtUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	return enc.AddArray("tags", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, t := range u.Tags {
+			arr.AppendString(t)
+		}
+		return nil
+	}))
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	enc := NewLogfmtEncoder(defaultLogfmtEncoderConfig)
+	enc.AddString("app", "test")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "main",
+		Message:    `say "hi"`,
+	}
+	fields := []zapcore.Field{
+		zap.Int("n", 42),
+		zap.Bool("ok", true),
+		zap.String("empty", ""),
+		zap.String("multi line", "a\nb\tc\x01"),
+		zap.String("eq", "a=b"),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Error(errors.New("failed")),
+		zap.Object("user", logfmtUser{Name: "jane doe", Tags: []string{"a", "b"}}),
+		zap.Ints("ids", []int{1, 2}),
+		zap.Namespace("ns"),
+		zap.Float64("f", 1.5),
+	}
+
+	buf, err := enc.EncodeEntry(ent, fields)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer buf.Free()
+
+	expected := `ts=2018-01-02T03:04:05.000Z level=warn logger=main msg="say \"hi\"" app=test ` +
+		`n=42 ok=true empty="" multi_line="a\nb\tc\u0001" eq="a=b" elapsed=1.5s error=failed ` +
+		`user.name="jane doe" user.tags.0=a user.tags.1=b ids.0=1 ids.1=2 ns.f=1.5` + "\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLogfmtEncoderClone(t *testing.T) {
+	enc := NewLogfmtEncoder(defaultLogfmtEncoderConfig)
+	enc.OpenNamespace("req")
+	clone := enc.Clone()
+	clone.AddString("id", "1")
+
+	ent := zapcore.Entry{Message: "hello"}
+	buf, err := clone.EncodeEntry(ent, []zapcore.Field{zap.String("path", "/")})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "level=info msg=hello req.id=1 req.path=/\n", buf.String())
+	}
+
+	buf, err = enc.EncodeEntry(ent, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "level=info msg=hello\n", buf.String())
+	}
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is a zap.Logger built by Config.Open or Configs.Open, which owns all the sinks
+// (files, lumberjack loggers and syslog connections) opened for it.
+//
+// It's safe for concurrent use.
+type Logger struct {
+	*zap.Logger
+
+	configs   Configs
+	errSink   zapcore.WriteSyncer
+	closers   closerGroup
+	stats     *loggerStats
+	files     []*reopenableFile
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Configs returns the configs of the targets the logger was built from.
+func (l *Logger) Configs() Configs {
+	return l.configs
+}
+
+// Stats returns the statistics of the logger.
+func (l *Logger) Stats() Stats {
+	return l.stats.snapshot()
+}
+
+// Sync flushes any buffered log entries, as well as internal errors written
+// to the error outputs.
+func (l *Logger) Sync() error {
+	err := l.Logger.Sync()
+	if l.errSink != nil {
+		err = multierr.Append(err, l.errSink.Sync())
+	}
+	return err
+}
+
+// Reopen reopens the files of the output paths and error output paths at the
+// same paths, e.g. after they are moved by logrotate. All the files are
+// switched at once, after the new ones are opened, so no entry is lost or
+// split between the old and new files. The old files are kept if any of them
+// fails to reopen.
+func (l *Logger) Reopen() error {
+	return reopenFiles(l.files)
+}
+
+// Close flushes the logger and releases all of its sinks. Errors from
+// flushing are ignored, since syncing standard outputs fails on most
+// platforms anyway.
+//
+// The logger must not be used after Close. Calling Close more than once
+// is a no-op.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		l.Sync()
+		l.closeErr = l.closers.Close()
+	})
+	return l.closeErr
+}
+
+// Stats reports the entries dropped by a Logger.
+type Stats struct {
+	// SampledOut is the number of entries dropped by sampling.
+	SampledOut uint64
+	// Dropped is the number of messages dropped by the syslog and GELF
+	// outputs, because their buffers were full or they were closed while
+	// disconnected, along with the entries dropped by buffered writes.
+	Dropped uint64
+	// Reconnects is the number of times the syslog and GELF outputs
+	// reconnected.
+	Reconnects uint64
+}
+
+type loggerStats struct {
+	sampledOut uint64
+	dropped    uint64
+	reconnects uint64
+}
+
+func (s *loggerStats) snapshot() Stats {
+	return Stats{
+		SampledOut: atomic.LoadUint64(&s.sampledOut),
+		Dropped:    atomic.LoadUint64(&s.dropped),
+		Reconnects: atomic.LoadUint64(&s.reconnects),
+	}
+}
+
+func (s *loggerStats) addDropped(n uint64) {
+	if s != nil {
+		atomic.AddUint64(&s.dropped, n)
+	}
+}
+
+func (s *loggerStats) addReconnects(n uint64) {
+	if s != nil {
+		atomic.AddUint64(&s.reconnects, n)
+	}
+}
+
+// closerGroup collects the release functions of opened sinks.
+type closerGroup []func() error
+
+func (g *closerGroup) add(fn func() error) {
+	*g = append(*g, fn)
+}
+
+func (g *closerGroup) addFunc(fn func()) {
+	g.add(func() error {
+		fn()
+		return nil
+	})
+}
+
+// Close releases sinks in the reverse order of opening.
+func (g closerGroup) Close() error {
+	var err error
+	for i := len(g) - 1; i >= 0; i-- {
+		err = multierr.Append(err, g[i]())
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestConfigOpenClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "output.log")
+	c := defaultConfigWith(
+		withOutputPaths([]string{output}),
+		withErrorOutputPaths([]string{"stderr"}),
+	)
+	c.Lumberjacks = []LumberjackConfig{{Filename: filepath.Join(dir, "lumberjack.log")}}
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l.Info("hello")
+	assert.NoError(t, l.Close())
+	assert.NoError(t, l.Close(), "Close should be idempotent")
+
+	for _, name := range []string{"output.log", "lumberjack.log"} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(b), `"msg":"hello"`, "unexpected content of %s", name)
+		}
+	}
+}
+
+func TestConfigOpenRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	c := defaultConfigWith(
+		withOutputPaths([]string{filepath.Join(dir, "output.log")}),
+		withErrorOutputPaths([]string{filepath.Join(dir, "missing", "error.log")}),
+	)
+
+	var closed int
+	b := &builder{stats: &loggerStats{}}
+	b.closers.addFunc(func() { closed++ })
+	_, err = Configs{c}.open(b)
+	assert.Error(t, err)
+	assert.Equal(t, 1, closed, "sinks opened before the failure should be closed")
+
+	// the output file was opened before the error output failed
+	if assert.Len(t, b.files, 1) {
+		_, err = b.files[0].Write([]byte("x"))
+		assert.Error(t, err, "output file should be closed")
+	}
+}
+
+func TestConfigsOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	withCaller := filepath.Join(dir, "caller.log")
+	withoutCaller := filepath.Join(dir, "nocaller.log")
+	cs := Configs{
+		defaultConfigWith(withOutputPaths([]string{withCaller}), withDisableCaller(false)),
+		defaultConfigWith(withEncoderType(ConsoleEncoder), withOutputPaths([]string{withoutCaller})),
+	}
+
+	l, err := cs.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("hello")
+	assert.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(withCaller)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `"caller":"log/logger_test.go`)
+	}
+	b, err = ioutil.ReadFile(withoutCaller)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "hello")
+		assert.NotContains(t, string(b), "logger_test.go")
+	}
+}
+
+func TestConfigOpenLevelRanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "app.log")
+	errOutput := filepath.Join(dir, "app.err")
+	c, err := ParseConfigFromURIString("logger:json?maxLevel=warn&outputPath=" + output + "&lumberjack=filename=" + errOutput + ",minlevel=error")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	assert.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(output)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `"msg":"info"`)
+		assert.Contains(t, string(b), `"msg":"warn"`)
+		assert.NotContains(t, string(b), `"msg":"error"`)
+	}
+	b, err = ioutil.ReadFile(errOutput)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(b), `"msg":"info"`)
+		assert.NotContains(t, string(b), `"msg":"warn"`)
+		assert.Contains(t, string(b), `"msg":"error"`)
+	}
+}
+
+func TestConfigOpenSampling(t *testing.T) {
+	c, err := ParseConfigFromURIString("logger:json?sampling.initial=2&sampling.thereafter=100&sampling.tick=1m")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c.OutputPaths = []string{}
+
+	var hooked int
+	c.Sampling.Hook = func(zapcore.Entry) { hooked++ }
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		l.Info("sampled")
+	}
+	assert.Equal(t, uint64(8), l.Stats().SampledOut)
+	assert.Equal(t, 8, hooked)
+}
+
+func TestConfigOpenEncoderConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "app.log")
+	c, err := ParseConfigFromURIString("logger:json?disableCaller=false&timeKey=@timestamp&timeEncoder=layout:2006&levelKey=severity&levelEncoder=capital&callerKey=-&functionKey=func&outputPath=" + output)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("hello")
+	assert.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(output)
+	if assert.NoError(t, err) {
+		assert.Regexp(t, `^\{"severity":"INFO","@timestamp":"\d{4}","msg":"hello","func":"[^"]+\.TestConfigOpenEncoderConfig"\}`, string(b))
+	}
+}
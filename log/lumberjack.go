@@ -1,6 +1,7 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -46,13 +47,59 @@ type LumberjackConfig struct {
 	// Compress determines if the rotated log files should be compressed
 	// using gzip.
 	Compress bool `json:"compress" yaml:"compress"`
+	// MinLevel and MaxLevel limit the entries written to the file to the
+	// given level range. Either one can be omitted.
+	MinLevel *zapcore.Level `json:"minlevel,omitempty" yaml:"minlevel,omitempty"`
+	MaxLevel *zapcore.Level `json:"maxlevel,omitempty" yaml:"maxlevel,omitempty"`
 }
 
-func parseLumberjack(s string) (*LumberjackConfig, error) {
-	c := LumberjackConfig{
-		MaxSize:    DefaultLumberjackMaxSize,
-		MaxBackups: DefaultLumberjackMaxBackups,
+// String returns the lumberjack config in the form accepted by
+// ParseLumberjacks.
+func (c LumberjackConfig) String() string {
+	s := fmt.Sprintf(
+		"filename=%s,maxsize=%d,maxage=%d,maxbackups=%d,localtime=%t,compress=%t",
+		c.Filename, c.MaxSize, c.MaxAge, c.MaxBackups, c.LocalTime, c.Compress,
+	)
+	if c.MinLevel != nil {
+		s += ",minlevel=" + c.MinLevel.String()
+	}
+	if c.MaxLevel != nil {
+		s += ",maxlevel=" + c.MaxLevel.String()
+	}
+	return s
+}
+
+var defaultLumberjackConfig = LumberjackConfig{
+	MaxSize:    DefaultLumberjackMaxSize,
+	MaxBackups: DefaultLumberjackMaxBackups,
+}
+
+// UnmarshalJSON implements json.Unmarshaler, missing settings are set to
+// their defaults.
+func (c *LumberjackConfig) UnmarshalJSON(data []byte) error {
+	type plain LumberjackConfig
+	v := plain(defaultLumberjackConfig)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
 	}
+	*c = LumberjackConfig(v)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, missing settings are set to
+// their defaults.
+func (c *LumberjackConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain LumberjackConfig
+	v := plain(defaultLumberjackConfig)
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	*c = LumberjackConfig(v)
+	return nil
+}
+
+func parseLumberjack(s string) (*LumberjackConfig, error) {
+	c := defaultLumberjackConfig
 
 	// Scan into a kv map
 	m := make(map[string]string)
@@ -110,6 +157,18 @@ func parseLumberjack(s string) (*LumberjackConfig, error) {
 				return nil, errors.WithMessage(err, fmt.Sprintf(errMessageFormat, key))
 			}
 			c.Compress = v
+		case "minlevel":
+			v, err := parseLevel(value)
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf(errMessageFormat, key))
+			}
+			c.MinLevel = v
+		case "maxlevel":
+			v, err := parseLevel(value)
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf(errMessageFormat, key))
+			}
+			c.MaxLevel = v
 		}
 	}
 
@@ -129,7 +188,7 @@ func ParseLumberjacks(vs ...string) ([]LumberjackConfig, error) {
 	return lumberjacks, nil
 }
 
-func openLumberjack(configs ...LumberjackConfig) zapcore.WriteSyncer {
+func openLumberjack(closers *closerGroup, configs ...LumberjackConfig) zapcore.WriteSyncer {
 	writers := make([]zapcore.WriteSyncer, 0, len(configs))
 
 	for _, config := range configs {
@@ -142,6 +201,7 @@ func openLumberjack(configs ...LumberjackConfig) zapcore.WriteSyncer {
 			Compress:   config.Compress,
 		}
 
+		closers.add(l.Close)
 		writers = append(writers, zapcore.AddSync(l))
 	}
 
@@ -0,0 +1,336 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var globalNameLevels = &nameLevelRegistry{}
+
+// maxLoggerNames caps the names recorded for LoggerNames, since the names may
+// be built from unbounded data.
+const maxLoggerNames = 1000
+
+// NameLevels are the levels of named loggers, keyed by the names, which
+// override the level of a config. A name applies to the children of the
+// logger too, e.g. "db" applies to "db" and "db.pool", and may contain
+// wildcards, e.g. "*.sql". The most specific name wins.
+type NameLevels map[string]zapcore.Level
+
+// String returns the levels in the form of "db=debug,http=warn".
+func (l NameLevels) String() string {
+	rules := make([]string, 0, len(l))
+	for name, level := range l {
+		rules = append(rules, name+"="+level.String())
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
+}
+
+// parseLevelRules parses levels in the form of "info,db=debug,http=warn",
+// the one without a name is the base level, which is nil if absent.
+func parseLevelRules(s string) (*zapcore.Level, NameLevels, error) {
+	var base *zapcore.Level
+	var levels NameLevels
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		i := strings.LastIndex(rule, "=")
+		level, err := parseLevel(rule[i+1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 {
+			base = level
+			continue
+		}
+
+		name := rule[:i]
+		if name == "" {
+			return nil, nil, fmt.Errorf("empty logger name: %q", rule)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, nil, fmt.Errorf("bad logger name pattern: %q", name)
+		}
+		if levels == nil {
+			levels = make(NameLevels)
+		}
+		levels[name] = *level
+	}
+	return base, levels, nil
+}
+
+// parseNameLevels parses levels in the form of "db=debug,http=warn".
+func parseNameLevels(s string) (NameLevels, error) {
+	base, levels, err := parseLevelRules(s)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		return nil, fmt.Errorf("missing logger name: %q", s)
+	}
+	return levels, nil
+}
+
+// setLevelRules sets the base level and the levels of named loggers, in the
+// form of "info,db=debug,http=warn".
+func setLevelRules(s string) error {
+	base, levels, err := parseLevelRules(s)
+	if err != nil {
+		return err
+	}
+	if base != nil {
+		baseLoggerLevel.SetLevel(*base)
+	}
+	setLoggerLevels(levels)
+	return nil
+}
+
+type nameLevelRule struct {
+	name     string
+	wildcard bool
+	level    zapcore.Level
+}
+
+func (r nameLevelRule) matches(name string) bool {
+	if !r.wildcard {
+		return name == r.name || strings.HasPrefix(name, r.name+".")
+	}
+	if ok, _ := path.Match(r.name, name); ok {
+		return true
+	}
+	ok, _ := path.Match(r.name+".*", name)
+	return ok
+}
+
+// specificity is the number of the characters of the name except the
+// wildcards.
+func (r nameLevelRule) specificity() int {
+	if !r.wildcard {
+		return len(r.name)
+	}
+	return len(r.name) - strings.Count(r.name, "*") - strings.Count(r.name, "?")
+}
+
+// nameLevelRules are the rules of NameLevels, ordered by specificity.
+type nameLevelRules struct {
+	rules []nameLevelRule
+	// min is the lowest level of the rules.
+	min zapcore.Level
+}
+
+func newNameLevelRules(levels NameLevels) *nameLevelRules {
+	if len(levels) == 0 {
+		return nil
+	}
+
+	r := &nameLevelRules{min: zapcore.FatalLevel}
+	for name, level := range levels {
+		r.rules = append(r.rules, nameLevelRule{
+			name:     name,
+			wildcard: strings.ContainsAny(name, "*?["),
+			level:    level,
+		})
+		if level < r.min {
+			r.min = level
+		}
+	}
+	sort.Slice(r.rules, func(i, j int) bool {
+		a, b := r.rules[i], r.rules[j]
+		if a.specificity() != b.specificity() {
+			return a.specificity() > b.specificity()
+		}
+		if a.wildcard != b.wildcard {
+			return !a.wildcard
+		}
+		return a.name < b.name
+	})
+	return r
+}
+
+// match returns the level of the most specific rule matching the name.
+func (r *nameLevelRules) match(name string) (zapcore.Level, bool) {
+	if r == nil || name == "" {
+		return 0, false
+	}
+	for _, rule := range r.rules {
+		if rule.matches(name) {
+			return rule.level, true
+		}
+	}
+	return 0, false
+}
+
+// enabled reports whether any rule enables the level.
+func (r *nameLevelRules) enabled(l zapcore.Level) bool {
+	return r != nil && l >= r.min
+}
+
+// nameLevelRegistry holds the levels of named loggers set at runtime, along
+// with the names of the loggers seen so far. The logging path reads them
+// without locking.
+type nameLevelRegistry struct {
+	mu     sync.Mutex
+	levels NameLevels
+	// rules is the *nameLevelRules of levels, replaced on every update.
+	rules atomic.Value
+
+	names     sync.Map
+	nameCount int32
+}
+
+func (g *nameLevelRegistry) get() *nameLevelRules {
+	r, _ := g.rules.Load().(*nameLevelRules)
+	return r
+}
+
+// update updates the levels with fn, which is called with a copy of them.
+func (g *nameLevelRegistry) update(fn func(NameLevels)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	levels := make(NameLevels, len(g.levels))
+	for name, level := range g.levels {
+		levels[name] = level
+	}
+	fn(levels)
+	g.levels = levels
+	g.rules.Store(newNameLevelRules(levels))
+}
+
+// addName records the name of a logger, up to maxLoggerNames names.
+func (g *nameLevelRegistry) addName(name string) {
+	if _, ok := g.names.Load(name); ok {
+		return
+	}
+	if atomic.LoadInt32(&g.nameCount) >= maxLoggerNames {
+		return
+	}
+	if _, loaded := g.names.LoadOrStore(name, struct{}{}); !loaded {
+		atomic.AddInt32(&g.nameCount, 1)
+	}
+}
+
+// LoggerNames returns the names of the loggers that have logged through the
+// loggers built by this package so far, in sorted order. Only the first 1000
+// names are recorded.
+func LoggerNames() []string {
+	var names []string
+	globalNameLevels.names.Range(func(name, _ interface{}) bool {
+		names = append(names, name.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// LoggerLevels returns the levels of named loggers set by the log.level flag
+// and SetLoggerLevel. They apply to all the loggers built by this package,
+// except where the levels of a config override them.
+func LoggerLevels() NameLevels {
+	g := globalNameLevels
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	levels := make(NameLevels, len(g.levels))
+	for name, level := range g.levels {
+		levels[name] = level
+	}
+	return levels
+}
+
+// SetLoggerLevel sets the level of the named logger and its children, the
+// name may contain wildcards.
+func SetLoggerLevel(name string, level zapcore.Level) error {
+	if name == "" {
+		return fmt.Errorf("empty logger name")
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return fmt.Errorf("bad logger name pattern: %q", name)
+	}
+	globalNameLevels.update(func(levels NameLevels) {
+		levels[name] = level
+	})
+	return nil
+}
+
+// UnsetLoggerLevel removes the level of the named logger set before.
+func UnsetLoggerLevel(name string) {
+	globalNameLevels.update(func(levels NameLevels) {
+		delete(levels, name)
+	})
+}
+
+// setLoggerLevels replaces the levels of named loggers.
+func setLoggerLevels(levels NameLevels) {
+	globalNameLevels.update(func(current NameLevels) {
+		for name := range current {
+			delete(current, name)
+		}
+		for name, level := range levels {
+			current[name] = level
+		}
+	})
+}
+
+// nameLevelEnabler enables the levels enabled by the base enabler, or by
+// the levels of any named logger.
+type nameLevelEnabler struct {
+	base  zapcore.LevelEnabler
+	local *nameLevelRules
+}
+
+func (e *nameLevelEnabler) Enabled(l zapcore.Level) bool {
+	return e.base.Enabled(l) || e.local.enabled(l) || globalNameLevels.get().enabled(l)
+}
+
+// nameLevelCore filters the entries by the levels of their loggers, the
+// levels of the config win over the ones set at runtime. The wrapped core
+// is enabled by nameLevelEnabler.
+type nameLevelCore struct {
+	zapcore.Core
+
+	base  zapcore.LevelEnabler
+	local *nameLevelRules
+}
+
+func (c *nameLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &nameLevelCore{
+		Core:  c.Core.With(fields),
+		base:  c.base,
+		local: c.local,
+	}
+}
+
+func (c *nameLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.LoggerName != "" {
+		globalNameLevels.addName(ent.LoggerName)
+	}
+
+	// without any rules, only the base level applies
+	global := globalNameLevels.get()
+	if c.local == nil && global == nil {
+		if !c.base.Enabled(ent.Level) {
+			return ce
+		}
+		return c.Core.Check(ent, ce)
+	}
+
+	level, ok := c.local.match(ent.LoggerName)
+	if !ok {
+		level, ok = global.match(ent.LoggerName)
+	}
+	if ok && ent.Level < level || !ok && !c.base.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
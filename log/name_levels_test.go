@@ -0,0 +1,104 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevelRules(t *testing.T) {
+	base, levels, err := parseLevelRules("warn, db=debug,*.sql=error")
+	if assert.NoError(t, err) {
+		assert.Equal(t, zapcore.WarnLevel, *base)
+		assert.Equal(t, NameLevels{"db": zapcore.DebugLevel, "*.sql": zapcore.ErrorLevel}, levels)
+	}
+
+	for _, s := range []string{"db=verbose", "=debug", "[=debug"} {
+		_, _, err := parseLevelRules(s)
+		assert.Error(t, err, s)
+	}
+	_, err = parseNameLevels("info,db=debug")
+	assert.Error(t, err)
+}
+
+func TestNameLevelRulesMatch(t *testing.T) {
+	rules := newNameLevelRules(NameLevels{
+		"db":      zapcore.DebugLevel,
+		"db.pool": zapcore.ErrorLevel,
+		"*.sql":   zapcore.WarnLevel,
+	})
+
+	fixtures := []struct {
+		name     string
+		level    zapcore.Level
+		expected bool
+	}{
+		{"db", zapcore.DebugLevel, true},
+		{"db.conn", zapcore.DebugLevel, true},
+		{"db.pool.conn", zapcore.ErrorLevel, true},
+		{"db.sql", zapcore.WarnLevel, true},
+		{"app.sql.tx", zapcore.WarnLevel, true},
+		{"dbx", 0, false},
+		{"", 0, false},
+	}
+	for _, f := range fixtures {
+		level, ok := rules.match(f.name)
+		assert.Equal(t, f.expected, ok, f.name)
+		assert.Equal(t, f.level, level, f.name)
+	}
+}
+
+func TestConfigsOpenNameLevels(t *testing.T) {
+	defer setLoggerLevels(nil)
+	defer baseLoggerLevel.SetLevel(baseLoggerLevel.Level())
+	baseLoggerLevel.SetLevel(zapcore.InfoLevel)
+
+	var buf bytes.Buffer
+	c, err := ParseConfigFromURIString("logger:logfmt?timeKey=-&levels=db=debug")
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := Configs{*c}.Open(zap.ErrorOutput(zapcore.AddSync(&buf)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	assert.NoError(t, SetLoggerLevel("http", zapcore.WarnLevel))
+	assert.Equal(t, NameLevels{"http": zapcore.WarnLevel}, LoggerLevels())
+
+	assert.NotNil(t, l.Named("db").Check(zapcore.DebugLevel, "a"))
+	assert.Nil(t, l.Named("app").Check(zapcore.DebugLevel, "b"))
+	assert.NotNil(t, l.Named("app").Check(zapcore.InfoLevel, "c"))
+	assert.Nil(t, l.Named("http").Check(zapcore.InfoLevel, "d"))
+	assert.NotNil(t, l.Named("http").Named("client").Check(zapcore.WarnLevel, "e"))
+
+	UnsetLoggerLevel("http")
+	assert.NotNil(t, l.Named("http").Check(zapcore.InfoLevel, "f"))
+
+	names := strings.Join(LoggerNames(), ",")
+	assert.Contains(t, names, "db")
+	assert.Contains(t, names, "http.client")
+}
+
+func TestNameLevelRegistryAddName(t *testing.T) {
+	g := &nameLevelRegistry{}
+	for i := 0; i < maxLoggerNames+10; i++ {
+		g.addName(strconv.Itoa(i))
+	}
+	g.addName("0")
+
+	var n int
+	g.names.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, maxLoggerNames, n)
+	_, ok := g.names.Load(strconv.Itoa(maxLoggerNames))
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ zapcore.WriteSyncer = &reopenableFile{}
+
+// reopenableFile is a file sink which can be reopened at the same path, e.g.
+// after it's moved by logrotate.
+type reopenableFile struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+func openReopenableFile(path string) (*reopenableFile, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &reopenableFile{path: path, f: f}, nil
+}
+
+func (f *reopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Write(p)
+}
+
+// Sync implements zapcore.WriteSyncer interface.
+func (f *reopenableFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Sync()
+}
+
+// Close closes the file.
+func (f *reopenableFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
+
+// reopenFiles reopens the files at once, no entry is written to the new files
+// until all of them are reopened. The files are left as is on error.
+func reopenFiles(files []*reopenableFile) error {
+	reopened := make([]*os.File, 0, len(files))
+	for _, file := range files {
+		f, err := openLogFile(file.path)
+		if err != nil {
+			for _, f := range reopened {
+				f.Close()
+			}
+			return err
+		}
+		reopened = append(reopened, f)
+	}
+
+	for _, file := range files {
+		file.mu.Lock()
+	}
+	for i, file := range files {
+		file.f, reopened[i] = reopened[i], file.f
+	}
+	for _, file := range files {
+		file.mu.Unlock()
+	}
+
+	var err error
+	for _, f := range reopened {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// openPaths opens the paths like zap.Open, except that the files can be
+// reopened by Logger.Reopen.
+func (b *builder) openPaths(paths ...string) (zapcore.WriteSyncer, error) {
+	writers := make([]zapcore.WriteSyncer, 0, len(paths))
+	for _, path := range paths {
+		switch path {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+			continue
+		case "stderr":
+			writers = append(writers, os.Stderr)
+			continue
+		}
+
+		f, err := openReopenableFile(path)
+		if err != nil {
+			return nil, err
+		}
+		b.closers.add(f.Close)
+		b.files = append(b.files, f)
+		writers = append(writers, f)
+	}
+	return zap.CombineWriteSyncers(writers...), nil
+}
+
+// parseSignal parses the name of a signal, e.g. "SIGHUP" or "hup".
+func parseSignal(name string) (os.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if sig, ok := reopenSignals[name]; ok {
+		return sig, nil
+	}
+	return nil, errors.Errorf("unsupported signal: %s", name)
+}
+
+// notifyReopen reopens the files of the logger on the signals, until the
+// returned function is called.
+func notifyReopen(l *Logger, sigs []os.Signal) func() {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := l.Reopen(); err != nil {
+					l.Error("error reopening log files", zap.Error(err))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
@@ -0,0 +1,15 @@
+// +build !windows
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals supported by reopenOnSignal.
+var reopenSignals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
@@ -0,0 +1,11 @@
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals supported by reopenOnSignal.
+var reopenSignals = map[string]os.Signal{
+	"SIGHUP": syscall.SIGHUP,
+}
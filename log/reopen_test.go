@@ -0,0 +1,82 @@
+// +build !windows
+
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reopen")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.log")
+	c, err := ParseConfigFromURIString("logger:logfmt?timeKey=-&outputPath=" + path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	l.Info("a")
+	assert.NoError(t, os.Rename(path, path+".1"))
+	l.Info("b")
+	assert.NoError(t, l.Reopen())
+	l.Info("c")
+
+	b, _ := ioutil.ReadFile(path + ".1")
+	assert.Equal(t, "level=info msg=a\nlevel=info msg=b\n", string(b))
+	b, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "level=info msg=c\n", string(b))
+
+	// the old files are kept if reopening fails
+	assert.NoError(t, os.RemoveAll(dir))
+	assert.Error(t, l.Reopen())
+}
+
+func TestConfigOpenReopenOnSignal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reopen")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = ParseConfigFromURIString("logger:json?reopenOnSignal=SIGFOO")
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "out.log")
+	c, err := ParseConfigFromURIString("logger:logfmt?timeKey=-&reopenOnSignal=hup&outputPath=" + path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.Info("a")
+	b, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "level=info msg=a\n", string(b))
+}
@@ -0,0 +1,174 @@
+package log
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var defaultSamplingConfig = SamplingConfig{
+	Initial:    100,
+	Thereafter: 100,
+	Tick:       time.Second,
+}
+
+// SamplingConfig sets a sampling strategy for the logger. Sampling caps the
+// global CPU and I/O load that logging puts on your process while attempting
+// to preserve a representative subset of your logs.
+//
+// Entries with the same level and message are logged for the first Initial
+// times each Tick, then every Thereafter-th entry is logged and the rest are
+// dropped. See zapcore.NewSampler for details.
+type SamplingConfig struct {
+	Initial    int           `json:"initial" yaml:"initial"`
+	Thereafter int           `json:"thereafter" yaml:"thereafter"`
+	Tick       time.Duration `json:"tick" yaml:"tick"`
+	// Hook, if set, is called for every entry dropped by sampling.
+	Hook func(zapcore.Entry) `json:"-" yaml:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The tick may be set as a string
+// parsed by time.ParseDuration, e.g. "1s", as well as in nanoseconds.
+func (c *SamplingConfig) UnmarshalJSON(data []byte) error {
+	type plain SamplingConfig
+	v := struct {
+		*plain
+		Tick json.RawMessage `json:"tick"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return unmarshalJSONDuration(v.Tick, &c.Tick)
+}
+
+// unmarshalJSONDuration decodes a duration from a string like "1s", or a
+// number of nanoseconds. It's left unchanged if data is empty.
+func unmarshalJSONDuration(data json.RawMessage, d *time.Duration) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return json.Unmarshal(data, (*int64)(d))
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func (c SamplingConfig) wrapCore(core zapcore.Core, stats *loggerStats) zapcore.Core {
+	tick := c.Tick
+	if tick <= 0 {
+		tick = defaultSamplingConfig.Tick
+	}
+	thereafter := c.Thereafter
+	if thereafter <= 0 {
+		thereafter = 1
+	}
+
+	return &samplingCore{
+		Core:       core,
+		counts:     &sampleCounters{},
+		tick:       tick,
+		first:      uint64(c.Initial),
+		thereafter: uint64(thereafter),
+		hook:       c.Hook,
+		stats:      stats,
+	}
+}
+
+const (
+	numSampledLevels = int(zapcore.FatalLevel-zapcore.DebugLevel) + 1
+	countersPerLevel = 4096
+)
+
+// sampleCounters are the counters of the entries seen in the current tick, by
+// level and hash of the message, like the ones of zapcore.NewSampler.
+type sampleCounters [numSampledLevels][countersPerLevel]sampleCounter
+
+func (cs *sampleCounters) get(level zapcore.Level, msg string) *sampleCounter {
+	i := int(level - zapcore.DebugLevel)
+	if i < 0 || i >= numSampledLevels {
+		i = numSampledLevels - 1
+	}
+	return &cs[i][fnv32a(msg)%countersPerLevel]
+}
+
+type sampleCounter struct {
+	resetAt int64
+	count   uint64
+}
+
+// incCheckReset increments the counter, or resets it to 1 if its tick is over.
+func (c *sampleCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > tn {
+		return atomic.AddUint64(&c.count, 1)
+	}
+
+	atomic.StoreUint64(&c.count, 1)
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, tn+tick.Nanoseconds()) {
+		// another goroutine reset it too, count this entry on top of it
+		return atomic.AddUint64(&c.count, 1)
+	}
+	return 1
+}
+
+// fnv32a is the FNV-1a hash of s, without converting it to a []byte.
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
+
+// samplingCore samples the entries like zapcore.NewSampler, and counts the
+// ones it drops.
+type samplingCore struct {
+	zapcore.Core
+
+	counts            *sampleCounters
+	tick              time.Duration
+	first, thereafter uint64
+	hook              func(zapcore.Entry)
+	stats             *loggerStats
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	t := ent.Time
+	if t.IsZero() {
+		// e.g. slog records without a time
+		t = time.Now()
+	}
+	n := c.counts.get(ent.Level, ent.Message).incCheckReset(t, c.tick)
+	if n > c.first && (n-c.first)%c.thereafter != 0 {
+		atomic.AddUint64(&c.stats.sampledOut, 1)
+		if c.hook != nil {
+			c.hook(ent)
+		}
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,288 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Schemas of the json encoder.
+const (
+	// ECSSchema is the Elastic Common Schema.
+	ECSSchema = "ecs"
+	// OTelSchema is the OpenTelemetry log data model.
+	OTelSchema = "otel"
+)
+
+const ecsVersion = "1.6.0"
+
+var schemaPool = buffer.NewPool()
+
+// schemaFields maps the portions of log entries to the fields defined by a
+// schema.
+type schemaFields struct {
+	// header returns the fields written before the context and the fields
+	// of the entry.
+	header func(ent zapcore.Entry) []zapcore.Field
+	// Keys of the error, caller and stacktrace, which are written along
+	// with the fields of the entry.
+	errorMessage string
+	errorType    string
+	stacktrace   string
+	file         string
+	line         string
+	function     string
+	// attributes, if set, is the key of the object holding the context and
+	// the fields of the entry.
+	attributes string
+}
+
+var (
+	ecsFields = schemaFields{
+		header: func(ent zapcore.Entry) []zapcore.Field {
+			var fields []zapcore.Field
+			if !ent.Time.IsZero() {
+				fields = append(fields, zap.String("@timestamp", ent.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00")))
+			}
+			fields = append(fields, zap.String("log.level", ent.Level.String()))
+			if ent.LoggerName != "" {
+				fields = append(fields, zap.String("log.logger", ent.LoggerName))
+			}
+			return append(fields,
+				zap.String("message", ent.Message),
+				zap.String("ecs.version", ecsVersion),
+			)
+		},
+		errorMessage: "error.message",
+		errorType:    "error.type",
+		stacktrace:   "error.stack_trace",
+		file:         "log.origin.file.name",
+		line:         "log.origin.file.line",
+		function:     "log.origin.function",
+	}
+	otelFields = schemaFields{
+		header: func(ent zapcore.Entry) []zapcore.Field {
+			var fields []zapcore.Field
+			if !ent.Time.IsZero() {
+				fields = append(fields, zap.Int64("Timestamp", ent.Time.UnixNano()))
+			}
+			fields = append(fields,
+				zap.String("SeverityText", ent.Level.CapitalString()),
+				zap.Int("SeverityNumber", otelSeverityNumber(ent.Level)),
+				zap.String("Body", ent.Message),
+			)
+			if ent.LoggerName != "" {
+				fields = append(fields, zap.Object("InstrumentationScope", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+					enc.AddString("Name", ent.LoggerName)
+					return nil
+				})))
+			}
+			return fields
+		},
+		errorMessage: "exception.message",
+		errorType:    "exception.type",
+		stacktrace:   "exception.stacktrace",
+		file:         "code.filepath",
+		line:         "code.lineno",
+		function:     "code.function",
+		attributes:   "Attributes",
+	}
+)
+
+// otelSeverityNumber maps the level to its OpenTelemetry severity number.
+func otelSeverityNumber(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 21
+	case zapcore.PanicLevel:
+		return 22
+	case zapcore.FatalLevel:
+		return 23
+	}
+	return 0
+}
+
+func parseSchema(s string) (*schemaFields, error) {
+	switch s {
+	case ECSSchema:
+		return &ecsFields, nil
+	case OTelSchema:
+		return &otelFields, nil
+	}
+	return nil, fmt.Errorf("unknown schema: %q", s)
+}
+
+// schemaEncoder writes log entries as JSON objects, with the fields defined
+// by a schema.
+type schemaEncoder struct {
+	// Encoder encodes the context and the fields of the entry, its keys of
+	// the entry are all empty.
+	zapcore.Encoder
+
+	fields     *schemaFields
+	header     zapcore.Encoder
+	lineEnding string
+	// context are the mapped fields of the context, e.g. the message of an
+	// error added by With.
+	context []zapcore.Field
+	// nested is true once a namespace is opened in the context.
+	nested bool
+}
+
+func newSchemaEncoder(schema string, cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	fields, err := parseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	cfg.TimeKey = ""
+	cfg.LevelKey = ""
+	cfg.NameKey = ""
+	cfg.CallerKey = ""
+	cfg.MessageKey = ""
+	cfg.StacktraceKey = ""
+	cfg.LineEnding = "\n"
+	return &schemaEncoder{
+		Encoder:    zapcore.NewJSONEncoder(cfg),
+		fields:     fields,
+		header:     zapcore.NewJSONEncoder(cfg),
+		lineEnding: lineEnding,
+	}, nil
+}
+
+func (enc *schemaEncoder) Clone() zapcore.Encoder {
+	return &schemaEncoder{
+		Encoder:    enc.Encoder.Clone(),
+		fields:     enc.fields,
+		header:     enc.header,
+		lineEnding: enc.lineEnding,
+		context:    enc.context[:len(enc.context):len(enc.context)],
+		nested:     enc.nested,
+	}
+}
+
+func (enc *schemaEncoder) OpenNamespace(key string) {
+	enc.nested = true
+	enc.Encoder.OpenNamespace(key)
+}
+
+// AddString maps the message of an error added to the context, zap adds
+// errors as strings.
+func (enc *schemaEncoder) AddString(key, val string) {
+	if key == "error" && !enc.nested {
+		enc.context = append(enc.context, zap.String(enc.fields.errorMessage, val))
+		return
+	}
+	enc.Encoder.AddString(key, val)
+}
+
+// mapFields returns the fields mapped to the ones of the schema, and the
+// rest of the fields.
+func (enc *schemaEncoder) mapFields(ent zapcore.Entry, fields []zapcore.Field) (mapped, rest []zapcore.Field) {
+	mapped = make([]zapcore.Field, 0, len(enc.context)+5)
+	if ent.Caller.Defined {
+		mapped = append(mapped,
+			zap.String(enc.fields.file, ent.Caller.File),
+			zap.Int(enc.fields.line, ent.Caller.Line),
+		)
+		if fn := runtime.FuncForPC(ent.Caller.PC); fn != nil {
+			mapped = append(mapped, zap.String(enc.fields.function, fn.Name()))
+		}
+	}
+	if ent.Stack != "" {
+		mapped = append(mapped, zap.String(enc.fields.stacktrace, ent.Stack))
+	}
+
+	rest = make([]zapcore.Field, 0, len(fields))
+	var hasError bool
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType && f.Key == "error" {
+			if err, ok := f.Interface.(error); ok && !hasError {
+				mapped = append(mapped,
+					zap.String(enc.fields.errorMessage, err.Error()),
+					zap.String(enc.fields.errorType, reflect.TypeOf(err).String()),
+				)
+				hasError = true
+				continue
+			}
+		}
+		rest = append(rest, f)
+	}
+	// the error of the entry wins over the one of the context
+	if !hasError {
+		mapped = append(mapped, enc.context...)
+	}
+	return mapped, rest
+}
+
+func (enc *schemaEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	header, err := enc.header.EncodeEntry(zapcore.Entry{}, enc.fields.header(ent))
+	if err != nil {
+		return nil, err
+	}
+	defer header.Free()
+
+	// The mapped fields are encoded apart from the context, which may have
+	// opened namespaces, and written before it.
+	mappedFields, fields := enc.mapFields(ent, fields)
+	mapped, err := enc.header.EncodeEntry(zapcore.Entry{}, mappedFields)
+	if err != nil {
+		return nil, err
+	}
+	defer mapped.Free()
+
+	body, err := enc.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Free()
+
+	buf := schemaPool.Get()
+	buf.AppendByte('{')
+	if enc.fields.attributes != "" {
+		appendMembers(buf, header)
+		buf.AppendString(`,"`)
+		buf.AppendString(enc.fields.attributes)
+		buf.AppendString(`":{`)
+		appendMembers(buf, mapped, body)
+		buf.AppendByte('}')
+	} else {
+		appendMembers(buf, header, mapped, body)
+	}
+	buf.AppendByte('}')
+	buf.AppendString(enc.lineEnding)
+	return buf, nil
+}
+
+// appendMembers splices the members of the JSON objects, each of them
+// followed by "\n", separated by commas.
+func appendMembers(buf *buffer.Buffer, objects ...*buffer.Buffer) {
+	sep := false
+	for _, o := range objects {
+		members := o.Bytes()[1 : o.Len()-2]
+		if len(members) == 0 {
+			continue
+		}
+		if sep {
+			buf.AppendByte(',')
+		}
+		buf.Write(members)
+		sep = true
+	}
+}
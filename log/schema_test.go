@@ -0,0 +1,139 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func encodeSchemaEntry(t *testing.T, schema string) map[string]interface{} {
+	enc, err := newSchemaEncoder(schema, defaultJSONEncoderConfig)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	enc.AddString("app", "test")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Date(2018, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		LoggerName: "main",
+		Message:    "failed",
+		Caller:     zapcore.NewEntryCaller(0, "/src/main.go", 42, true),
+		Stack:      "goroutine 1",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.Error(errors.New("boom")),
+		zap.Int("n", 1),
+	})
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	assert.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	return m
+}
+
+func TestSchemaEncoderECS(t *testing.T) {
+	m := encodeSchemaEntry(t, ECSSchema)
+	assert.Equal(t, map[string]interface{}{
+		"@timestamp":           "2018-01-02T03:04:05.006Z",
+		"log.level":            "error",
+		"log.logger":           "main",
+		"message":              "failed",
+		"ecs.version":          ecsVersion,
+		"app":                  "test",
+		"log.origin.file.name": "/src/main.go",
+		"log.origin.file.line": float64(42),
+		"error.stack_trace":    "goroutine 1",
+		"error.message":        "boom",
+		"error.type":           "*errors.errorString",
+		"n":                    float64(1),
+	}, m)
+}
+
+func TestSchemaEncoderOTel(t *testing.T) {
+	m := encodeSchemaEntry(t, OTelSchema)
+	assert.Equal(t, map[string]interface{}{
+		"Timestamp":            float64(time.Date(2018, 1, 2, 3, 4, 5, 6000000, time.UTC).UnixNano()),
+		"SeverityText":         "ERROR",
+		"SeverityNumber":       float64(17),
+		"Body":                 "failed",
+		"InstrumentationScope": map[string]interface{}{"Name": "main"},
+		"Attributes": map[string]interface{}{
+			"app":                  "test",
+			"code.filepath":        "/src/main.go",
+			"code.lineno":          float64(42),
+			"exception.stacktrace": "goroutine 1",
+			"exception.message":    "boom",
+			"exception.type":       "*errors.errorString",
+			"n":                    float64(1),
+		},
+	}, m)
+}
+
+func TestSchemaEncoderEmptyFields(t *testing.T) {
+	enc, err := newSchemaEncoder(ECSSchema, defaultJSONEncoderConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := enc.EncodeEntry(zapcore.Entry{Time: time.Unix(0, 0)}, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"@timestamp":"1970-01-01T00:00:00.000Z","log.level":"info","message":"","ecs.version":"1.6.0"}`+"\n", buf.String())
+	}
+
+	_, err = ParseConfigFromURIString("logger:json?schema=unknown")
+	assert.Error(t, err)
+}
+
+func TestSchemaEncoderNamespacedContext(t *testing.T) {
+	for _, schema := range []string{ECSSchema, OTelSchema} {
+		enc, err := newSchemaEncoder(schema, defaultJSONEncoderConfig)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var buf bytes.Buffer
+		core := zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel)
+		logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+		logger.With(zap.Error(errors.New("ctx"))).With(zap.Namespace("req"), zap.Int("id", 1)).Error("failed", zap.Int("n", 1))
+		logger.With(zap.Error(errors.New("ctx"))).Warn("failed", zap.Error(errors.New("boom")))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if !assert.Len(t, lines, 2, "schema %s", schema) {
+			continue
+		}
+		var m []map[string]interface{}
+		for _, line := range lines {
+			var v map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(line), &v), line)
+			if schema == OTelSchema {
+				v, _ = v["Attributes"].(map[string]interface{})
+			}
+			m = append(m, v)
+		}
+
+		f := ecsFields
+		if schema == OTelSchema {
+			f = otelFields
+		}
+		assert.Equal(t, "ctx", m[0][f.errorMessage], "schema %s", schema)
+		assert.Contains(t, m[0], f.file, "schema %s", schema)
+		assert.Contains(t, m[0], f.line, "schema %s", schema)
+		assert.Contains(t, m[0], f.stacktrace, "schema %s", schema)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "n": float64(1)}, m[0]["req"], "schema %s", schema)
+
+		// the error of the entry wins over the one of the context
+		assert.Equal(t, "boom", m[1][f.errorMessage], "schema %s", schema)
+		assert.Equal(t, "*errors.errorString", m[1][f.errorType], "schema %s", schema)
+		assert.NotContains(t, m[1], "error", "schema %s", schema)
+		assert.NotContains(t, lines[1], `"ctx"`, "schema %s", schema)
+	}
+}
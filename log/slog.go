@@ -0,0 +1,239 @@
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	stdlog "log"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	replaceSlogDefault = func(opts SlogHandlerOptions) func() {
+		prev := slog.Default()
+		// slog.SetDefault redirects the standard logger to the handler
+		prevOutput, prevFlags := stdlog.Writer(), stdlog.Flags()
+		slog.SetDefault(slog.New(NewSlogHandler(&globalCore{}, opts)))
+		return func() {
+			slog.SetDefault(prev)
+			stdlog.SetOutput(prevOutput)
+			stdlog.SetFlags(prevFlags)
+		}
+	}
+}
+
+var _ slog.Handler = &slogHandler{}
+
+// slogHandler is a slog.Handler writing to a zapcore.Core.
+type slogHandler struct {
+	core zapcore.Core
+	opts SlogHandlerOptions
+	// groups are the groups opened by WithGroup, which aren't added to the
+	// core until there are attrs in them, since empty groups are omitted.
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing to the core, e.g. the core
+// of the global logger, log.L().Core(). Groups are encoded as namespaces,
+// and the slog levels are mapped to the zap levels at or below them, e.g.
+// slog.LevelWarn+2 is logged as warn.
+func NewSlogHandler(core zapcore.Core, opts SlogHandlerOptions) slog.Handler {
+	return &slogHandler{core: core, opts: opts}
+}
+
+// SlogHandler returns a slog.Handler writing to the targets of the logger,
+// with the callers and stacktraces annotated like the ones of the logger.
+func (l *Logger) SlogHandler() slog.Handler {
+	opts := SlogHandlerOptions{AddSource: l.configs.addCaller()}
+	if stackLevel := l.configs.stackLevel(); stackLevel <= zapcore.FatalLevel {
+		opts.AddStacktrace = stackLevel
+	}
+	return NewSlogHandler(l.Core(), opts)
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevelOf(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	// a zero r.Time is left zero, which the encoders of the configs leave
+	// out of the entry
+	ent := zapcore.Entry{
+		Level:   zapLevelOf(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	if h.opts.AddStacktrace != nil && h.opts.AddStacktrace.Enabled(ent.Level) {
+		ce.Entry.Stack = slogStacktrace()
+	}
+
+	fields := extractFields(ctx, nil)
+	attrs := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, attr)
+		return true
+	})
+	if len(attrs) > 0 {
+		fields = h.appendGroups(fields)
+		fields = append(fields, attrs...)
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+
+	return &slogHandler{
+		core: h.core.With(append(h.appendGroups(nil), fields...)),
+		opts: h.opts,
+	}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+	return &slogHandler{
+		core:   h.core,
+		opts:   h.opts,
+		groups: append(groups, name),
+	}
+}
+
+// appendGroups appends the namespaces of the pending groups.
+func (h *slogHandler) appendGroups(fields []zapcore.Field) []zapcore.Field {
+	for _, group := range h.groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+	return fields
+}
+
+// slogStacktrace returns the stacktrace of the caller of the slog functions
+// calling Handle, formatted like the ones of zap.
+func slogStacktrace() string {
+	// skip runtime.Callers, slogStacktrace and Handle
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	for n == len(pcs) {
+		pcs = make([]uintptr, len(pcs)*2)
+		n = runtime.Callers(3, pcs)
+	}
+
+	var b strings.Builder
+	skipping := true
+	frames := runtime.CallersFrames(pcs[:n])
+	// the last frame is either runtime.main or runtime.goexit, like zap
+	for frame, more := frames.Next(); more; frame, more = frames.Next() {
+		if skipping && strings.HasPrefix(frame.Function, "log/slog.") {
+			continue
+		}
+		skipping = false
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+	return b.String()
+}
+
+// zapLevelOf returns the zap level at or below the slog level.
+func zapLevelOf(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// appendSlogAttr appends the field of the attr, the attrs of groups without
+// a key are appended inline, and empty attrs are omitted.
+func appendSlogAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	v := attr.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, v.Time()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			for _, a := range attrs {
+				fields = appendSlogAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(attrs)))
+	default:
+		if err, ok := v.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, v.Any()))
+	}
+}
+
+// slogGroup marshals the attrs of a group as an object.
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zapcore.Field
+	for _, attr := range g {
+		fields = appendSlogAttr(fields, attr)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
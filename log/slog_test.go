@@ -0,0 +1,138 @@
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type slogUser string
+
+func (u slogUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", string(u)))
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.InfoLevel)
+
+	l := slog.New(NewSlogHandler(core, SlogHandlerOptions{AddSource: true}))
+	l.Debug("hidden")
+	l.Info("a", "n", 1, "d", time.Second, "err", errors.New("failed"), slog.Group("g", "x", true))
+	l.With("app", "test").WithGroup("req").With("id", 7).WithGroup("empty").Log(nil, slog.LevelWarn+2, "b", "user", slogUser("jane"))
+	l.WithGroup("empty").Error("c", slog.Group("", "y", 2), slog.Group("none"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"level":"info","caller":"log/slog_test.go:`)
+		assert.Contains(t, lines[0], `"msg":"a","n":1,"d":1,"err":"failed","g":{"x":true}}`)
+		assert.Contains(t, lines[1], `"level":"warn"`)
+		assert.Contains(t, lines[1], `"msg":"b","app":"test","req":{"id":7,"empty":{"user":{"name":"jane"}}}}`)
+		assert.Contains(t, lines[2], `"level":"error"`)
+		assert.Contains(t, lines[2], `"msg":"c","empty":{"y":2}}`)
+	}
+}
+
+func TestReplaceGlobalsSlogDefault(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.InfoLevel)
+
+	restore := ReplaceGlobals(zap.New(core), WithSlogDefault(SlogHandlerOptions{}))
+	slog.Info("a")
+	L().Info("b")
+
+	// the default logger keeps up with the global logger
+	var replaced bytes.Buffer
+	replacedCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&replaced), zapcore.InfoLevel)
+	restoreReplaced := ReplaceGlobals(zap.New(replacedCore))
+	slog.With("n", 1).Info("c")
+	restoreReplaced()
+	slog.Info("d")
+
+	restore()
+	slog.Info("e")
+
+	assert.Equal(t, "{\"level\":\"info\",\"msg\":\"a\"}\n{\"level\":\"info\",\"msg\":\"b\"}\n{\"level\":\"info\",\"msg\":\"d\"}\n", buf.String())
+	assert.Equal(t, "{\"level\":\"info\",\"msg\":\"c\",\"n\":1}\n", replaced.String())
+}
+
+func TestSlogHandlerStacktrace(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.InfoLevel)
+
+	l := slog.New(NewSlogHandler(core, SlogHandlerOptions{AddStacktrace: zapcore.ErrorLevel}))
+	l.Warn("a")
+	l.Error("b")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.NotContains(t, lines[0], `"stacktrace"`)
+		assert.Contains(t, lines[1], `"msg":"b","stacktrace":"github.com/imperfectgo/common/log.TestSlogHandlerStacktrace\n\t`)
+	}
+}
+
+func TestSlogHandlerZeroTime(t *testing.T) {
+	ecs, err := newSchemaEncoder("ecs", defaultJSONEncoderConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	otel, err := newSchemaEncoder("otel", defaultJSONEncoderConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	gelfCfg := defaultGELFEncoderConfig
+	gelfCfg.Hostname = "web1"
+
+	fixtures := []struct {
+		enc      zapcore.Encoder
+		expected string
+		timeKey  string
+	}{
+		{newJSONEncoder(defaultJSONEncoderConfig), "{\"level\":\"info\",\"msg\":\"a\",\"n\":1}\n", `"ts":`},
+		{newConsoleEncoder(defaultConsoleEncoderConfig), "\x1b[34mINFO\x1b[0m\ta\t{\"n\": 1}\n", "1970-"},
+		{NewLogfmtEncoder(defaultLogfmtEncoderConfig), "level=info msg=a n=1\n", "ts="},
+		{ecs, "", `"@timestamp":`},
+		{otel, "", `"Timestamp":`},
+		{NewGELFEncoder(gelfCfg), "", `"timestamp":`},
+	}
+
+	for i, f := range fixtures {
+		var buf bytes.Buffer
+		core := zapcore.NewCore(f.enc, zapcore.AddSync(&buf), zapcore.InfoLevel)
+		h := NewSlogHandler(core, SlogHandlerOptions{})
+
+		r := slog.NewRecord(time.Time{}, slog.LevelInfo, "a", 0)
+		r.AddAttrs(slog.Int("n", 1))
+		if !assert.NoError(t, h.Handle(context.Background(), r)) {
+			return
+		}
+		if f.expected != "" {
+			assert.Equal(t, f.expected, buf.String(), "at index %d", i)
+		}
+		assert.NotContains(t, buf.String(), f.timeKey, "zero time logged, at index %d", i)
+		assert.NotContains(t, buf.String(), "0001-01-01", "zero time logged, at index %d", i)
+
+		buf.Reset()
+		r.Time = time.Unix(1, 0)
+		if !assert.NoError(t, h.Handle(context.Background(), r)) {
+			return
+		}
+		assert.Contains(t, buf.String(), f.timeKey, "time not logged, at index %d", i)
+	}
+}
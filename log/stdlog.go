@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// writerDepth is the caller skip of lineWriter, up to the caller of
+	// Write.
+	writerDepth = 1
+	// stdLogDepth is the caller skip of the standard logger, up to the caller
+	// of its functions such as log.Printf.
+	stdLogDepth = 2
+)
+
+// WithStdLogRedirect makes ReplaceGlobals redirect the output of the standard
+// library log package to the global logger, each line is logged as an entry
+// at the level. It keeps up with later replacements of the global logger,
+// and it's restored along with the globals.
+func WithStdLogRedirect(level zapcore.Level) GlobalsOption {
+	return func(o *globalsOptions) {
+		o.stdLogLevel = &level
+	}
+}
+
+// redirectStdLog redirects the output of the standard logger to the global
+// logger, and returns a function to restore it.
+func redirectStdLog(level zapcore.Level) func() {
+	prevOutput, prevFlags, prevPrefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(newLineWriter(L, level, writerDepth+stdLogDepth, nil))
+	return func() {
+		stdlog.SetOutput(prevOutput)
+		stdlog.SetFlags(prevFlags)
+		stdlog.SetPrefix(prevPrefix)
+	}
+}
+
+// StdLogger returns a standard library logger writing to the global logger,
+// e.g. for http.Server.ErrorLog. Each line is logged as an entry at the
+// level, with the fields.
+func StdLogger(level zapcore.Level, fields ...zapcore.Field) *stdlog.Logger {
+	return stdlog.New(newLineWriter(L, level, writerDepth+stdLogDepth, fields), "", 0)
+}
+
+// Writer returns an io.Writer writing to the global logger. The written bytes
+// are split into lines, each of them is logged as an entry at the level, with
+// the fields. An incomplete line is held until it's completed by the next
+// writes.
+//
+// It's safe for concurrent use.
+func Writer(level zapcore.Level, fields ...zapcore.Field) io.Writer {
+	return newLineWriter(L, level, writerDepth, fields)
+}
+
+// lineWriter logs the lines written to it.
+type lineWriter struct {
+	logger func() *zap.Logger
+	level  zapcore.Level
+	skip   int
+	fields []zapcore.Field
+
+	mu sync.Mutex
+	// base and derived cache the logger with the caller skip and fields, as
+	// long as logger returns the same one.
+	base    *zap.Logger
+	derived *zap.Logger
+	buf     []byte
+}
+
+func newLineWriter(logger func() *zap.Logger, level zapcore.Level, skip int, fields []zapcore.Field) *lineWriter {
+	return &lineWriter{
+		logger: logger,
+		level:  level,
+		skip:   skip,
+		fields: fields,
+	}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	if len(w.buf) > 0 {
+		p = append(w.buf, p...)
+		w.buf = nil
+	}
+
+	logger := w.derivedLogger()
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(p[:i], []byte("\r"))
+		p = p[i+1:]
+		if len(line) == 0 {
+			continue
+		}
+		if ce := logger.Check(w.level, string(line)); ce != nil {
+			ce.Write()
+		}
+	}
+	w.buf = append(w.buf, p...)
+	return n, nil
+}
+
+func (w *lineWriter) derivedLogger() *zap.Logger {
+	base := w.logger()
+	if base != w.base {
+		w.base = base
+		w.derived = base.WithOptions(zap.AddCallerSkip(w.skip)).With(w.fields...)
+	}
+	return w.derived
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	stdlog "log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStdLogRedirect(t *testing.T) {
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.DebugLevel)
+
+	var std bytes.Buffer
+	defer stdlog.SetOutput(stdlog.Writer())
+	stdlog.SetOutput(&std)
+
+	restore := ReplaceGlobals(zap.New(core, zap.AddCaller()), WithStdLogRedirect(zapcore.WarnLevel))
+	stdlog.Printf("a")
+	StdLogger(zapcore.ErrorLevel, zap.String("component", "http")).Println("b\nc")
+	w := Writer(zapcore.InfoLevel)
+	fmt.Fprint(w, "d\r\n\ne")
+	fmt.Fprint(w, "f\n")
+	restore()
+	stdlog.Print("g")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 5) {
+		assert.Contains(t, lines[0], `{"level":"warn","caller":"log/stdlog_test.go:`)
+		assert.Contains(t, lines[0], `"msg":"a"}`)
+		assert.Contains(t, lines[1], `{"level":"error","caller":"log/stdlog_test.go:`)
+		assert.Contains(t, lines[1], `"msg":"b","component":"http"}`)
+		assert.Contains(t, lines[2], `"msg":"c","component":"http"}`)
+		assert.Contains(t, lines[3], `{"level":"info","caller":"fmt/print.go:`)
+		assert.Contains(t, lines[3], `"msg":"d"}`)
+		assert.Contains(t, lines[4], `"msg":"ef"}`)
+	}
+	assert.Contains(t, std.String(), "g\n")
+}
+
+func TestStdLogRedirectReplaced(t *testing.T) {
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	var buf, replaced bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	replacedCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&replaced), zapcore.DebugLevel)
+
+	defer stdlog.SetOutput(stdlog.Writer())
+	stdlog.SetOutput(ioutil.Discard)
+
+	restore := ReplaceGlobals(zap.New(core), WithStdLogRedirect(zapcore.WarnLevel))
+	defer restore()
+	stdlog.Print("a")
+	restoreReplaced := ReplaceGlobals(zap.New(replacedCore))
+	stdlog.Print("b")
+	restoreReplaced()
+	stdlog.Print("c")
+
+	assert.Equal(t, "{\"level\":\"warn\",\"msg\":\"a\"}\n{\"level\":\"warn\",\"msg\":\"c\"}\n", buf.String())
+	assert.Equal(t, "{\"level\":\"warn\",\"msg\":\"b\"}\n", replaced.String())
+}
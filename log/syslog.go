@@ -0,0 +1,421 @@
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 10 * time.Second
+
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// localSyslogPaths are the usual paths of the local syslog socket.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var (
+	_ zapcore.WriteSyncer = &connSyncer{}
+
+	errSinkClosed = errors.New("log: write to closed sink")
+)
+
+// findLocalSyslog returns the output address of the local syslog socket,
+// e.g. "unixgram:/dev/log".
+func findLocalSyslog() (string, error) {
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, path, dialTimeout)
+			if err == nil {
+				conn.Close()
+				return network + ":" + path, nil
+			}
+		}
+	}
+	return "", errors.New("config: no output address for syslog, and no local syslog socket found")
+}
+
+// DropPolicy decides which messages are dropped when the buffer of a
+// disconnected output is full.
+type DropPolicy int
+
+// DropPolicy.
+const (
+	// DropOldest drops the oldest buffered messages to make room.
+	DropOldest DropPolicy = iota
+	// DropNewest drops the new messages.
+	DropNewest
+	// Block blocks logging until there is room.
+	Block
+)
+
+var dropPolicyNames = map[DropPolicy]string{
+	DropOldest: "drop-oldest",
+	DropNewest: "drop-newest",
+	Block:      "block",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p DropPolicy) MarshalText() ([]byte, error) {
+	if name, ok := dropPolicyNames[p]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown drop policy: %d", int(p))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *DropPolicy) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	if name == "" {
+		*p = DropOldest
+		return nil
+	}
+	for k, v := range dropPolicyNames {
+		if v == name {
+			*p = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown drop policy: %q", text)
+}
+
+// BufferConfig configures the buffer of the connections of syslog and GELF
+// outputs, which holds the messages while they are disconnected.
+type BufferConfig struct {
+	// Size is the maximum number of buffered messages, zero means no limit.
+	Size int `json:"size" yaml:"size"`
+	// Bytes is the maximum total size of buffered messages, zero means no
+	// limit.
+	Bytes      int        `json:"bytes" yaml:"bytes"`
+	DropPolicy DropPolicy `json:"dropPolicy" yaml:"dropPolicy"`
+}
+
+// set sets the setting by its name in logger URIs, it returns false if the
+// name is unknown.
+func (c *BufferConfig) set(k, v string) (bool, error) {
+	switch k {
+	case "bufferSize":
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return true, err
+		}
+		c.Size = n
+	case "bufferBytes":
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return true, err
+		}
+		c.Bytes = n
+	case "dropPolicy":
+		if err := c.DropPolicy.UnmarshalText([]byte(v)); err != nil {
+			return true, err
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// addValues adds the settings to the values of logger URIs.
+func (c BufferConfig) addValues(values url.Values) error {
+	policy, err := c.DropPolicy.MarshalText()
+	if err != nil {
+		return err
+	}
+	values.Set("bufferSize", strconv.Itoa(c.Size))
+	values.Set("bufferBytes", strconv.Itoa(c.Bytes))
+	values.Set("dropPolicy", string(policy))
+	return nil
+}
+
+// connSyncer describes connection sink for syslog. Unlike
+// zapsyslog.ConnSyncer, it's safe for concurrent use and can be closed.
+//
+// Messages are written by a background goroutine, which dials lazily and
+// reconnects with exponential backoff. The messages are buffered while it's
+// disconnected.
+type connSyncer struct {
+	network string
+	raddr   string
+	dial    func(network, raddr string) (net.Conn, error)
+	buffer  BufferConfig
+	stats   *loggerStats
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	pending      []connMessage
+	pendingBytes int
+	// inflight is set while a message taken from pending is being written.
+	inflight bool
+	// failing is set while the connection is down and being retried.
+	failing bool
+	closed  bool
+	closing chan struct{}
+	done    chan struct{}
+}
+
+func newConnSyncer(network, raddr string, buffer BufferConfig, stats *loggerStats) *connSyncer {
+	return newDialConnSyncer(network, raddr, func(network, raddr string) (net.Conn, error) {
+		return net.DialTimeout(network, raddr, dialTimeout)
+	}, buffer, stats)
+}
+
+// newTLSConnSyncer creates a connSyncer connecting to the TCP address with
+// TLS.
+func newTLSConnSyncer(raddr string, config *tls.Config, buffer BufferConfig, stats *loggerStats) *connSyncer {
+	return newDialConnSyncer("tcp", raddr, func(network, raddr string) (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, network, raddr, config)
+	}, buffer, stats)
+}
+
+func newDialConnSyncer(network, raddr string, dial func(network, raddr string) (net.Conn, error), buffer BufferConfig, stats *loggerStats) *connSyncer {
+	s := &connSyncer{
+		network: network,
+		raddr:   raddr,
+		dial:    dial,
+		buffer:  buffer,
+		stats:   stats,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	go s.run()
+	return s
+}
+
+// connMessage is a message buffered by connSyncer, written to the connection
+// with one write per part, e.g. the chunks of a GELF message.
+type connMessage [][]byte
+
+func (m connMessage) size() int {
+	n := 0
+	for _, part := range m {
+		n += len(part)
+	}
+	return n
+}
+
+// Write buffers the message to be written by the background goroutine. When
+// the buffer is full, the message is handled by the drop policy.
+func (s *connSyncer) Write(p []byte) (int, error) {
+	if err := s.writeParts(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeParts buffers a message made of the parts, which are written to the
+// connection one by one. The parts are buffered, dropped or evicted as a
+// whole, so a message is never written partially.
+func (s *connSyncer) writeParts(parts ...[]byte) error {
+	msg := make(connMessage, len(parts))
+	for i, part := range parts {
+		msg[i] = append([]byte(nil), part...)
+	}
+	size := msg.size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.closed {
+			return errSinkClosed
+		}
+		if !s.full(size) {
+			break
+		}
+
+		if len(s.pending) == 0 {
+			// too large to be buffered at all
+			s.stats.addDropped(1)
+			return nil
+		}
+		switch s.buffer.DropPolicy {
+		case DropNewest:
+			s.stats.addDropped(1)
+			return nil
+		case Block:
+			s.cond.Wait()
+		default:
+			s.pendingBytes -= s.pending[0].size()
+			s.pending[0] = nil
+			s.pending = s.pending[1:]
+			s.stats.addDropped(1)
+		}
+	}
+
+	s.pending = append(s.pending, msg)
+	s.pendingBytes += size
+	s.cond.Broadcast()
+	return nil
+}
+
+// full reports whether there is no room for a message of size n.
+func (s *connSyncer) full(n int) bool {
+	return (s.buffer.Size > 0 && len(s.pending)+1 > s.buffer.Size) ||
+		(s.buffer.Bytes > 0 && s.pendingBytes+n > s.buffer.Bytes)
+}
+
+// run writes the buffered messages until the syncer is closed.
+func (s *connSyncer) run() {
+	defer close(s.done)
+
+	var conn net.Conn
+	var connected bool
+	backoff := minReconnectBackoff
+	for {
+		s.mu.Lock()
+		for len(s.pending) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			break
+		}
+		msg := s.pending[0]
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+		s.pendingBytes -= msg.size()
+		s.inflight = true
+		closed := s.closed
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		for {
+			var err error
+			if conn == nil {
+				if conn, err = s.dial(s.network, s.raddr); err == nil && connected {
+					s.stats.addReconnects(1)
+				}
+			}
+			if err == nil {
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				// the parts written already aren't written again
+				for len(msg) > 0 {
+					if _, err = conn.Write(msg[0]); err != nil {
+						// ignore err from close, it makes sense to continue anyway
+						conn.Close()
+						conn = nil
+						break
+					}
+					msg = msg[1:]
+				}
+			}
+			if err == nil {
+				connected = true
+				backoff = minReconnectBackoff
+				s.setFailing(false)
+				break
+			}
+
+			if closed {
+				// give up on the messages left on close
+				s.dropPending(1)
+				break
+			}
+			s.setFailing(true)
+			select {
+			case <-time.After(backoff):
+			case <-s.closing:
+				closed = true
+			}
+			if backoff *= 2; backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+		}
+	}
+
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func (s *connSyncer) setFailing(failing bool) {
+	s.mu.Lock()
+	s.failing = failing
+	s.inflight = s.inflight && failing
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// dropPending drops all the buffered messages, along with n messages taken
+// from the buffer.
+func (s *connSyncer) dropPending(n int) {
+	s.mu.Lock()
+	s.stats.addDropped(uint64(len(s.pending) + n))
+	s.pending = nil
+	s.pendingBytes = 0
+	s.inflight = false
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// Sync waits for the buffered messages to be written, unless the connection
+// is down.
+func (s *connSyncer) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for (len(s.pending) > 0 || s.inflight) && !s.failing && !s.closed {
+		s.cond.Wait()
+	}
+	return nil
+}
+
+// Close writes the buffered messages if connected, and closes the
+// connection.
+func (s *connSyncer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		<-s.done
+		return nil
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	close(s.closing)
+	<-s.done
+	return nil
+}
+
+// octetCountedSyncer reframes the messages encoded with non-transparent
+// framing to octet-counting framing, as required by TLS connections.
+type octetCountedSyncer struct {
+	mu   sync.Mutex
+	conn *connSyncer
+	buf  []byte
+}
+
+func (s *octetCountedSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// SYSLOG-FRAME = MSG-LEN SP SYSLOG-MSG
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	s.buf = strconv.AppendInt(s.buf[:0], int64(len(msg)), 10)
+	s.buf = append(append(s.buf, ' '), msg...)
+	if _, err := s.conn.Write(s.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer interface.
+func (s *octetCountedSyncer) Sync() error {
+	return s.conn.Sync()
+}
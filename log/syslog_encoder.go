@@ -0,0 +1,318 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/imperfectgo/zap-syslog"
+	"github.com/imperfectgo/zap-syslog/syslog"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	syslogNilValue        = "-"
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	maxHostnameLen = 255
+	maxAppNameLen  = 48
+	maxMsgIDLen    = 32
+	maxTagLen      = 32
+	maxSDNameLen   = 32
+)
+
+var syslogPool = buffer.NewPool()
+
+// SyslogEncoderConfig configures the syslog encoder.
+type SyslogEncoderConfig struct {
+	// EncoderConfig configures the JSON encoded MSG of messages.
+	zapcore.EncoderConfig
+
+	Format   SyslogFormat
+	Framing  zapsyslog.Framing
+	Facility syslog.Priority
+	// Hostname, PID and App are detected if unset, App defaults to the
+	// base name of os.Args[0].
+	Hostname string
+	PID      int
+	App      string
+	// MsgIDKey, if set, is the key of the string field used as the MSGID of
+	// RFC5424 messages, the logger name is used if the field is absent.
+	MsgIDKey string
+}
+
+// sdElement is an RFC5424 STRUCTURED-DATA element.
+type sdElement struct {
+	id     string
+	params *flatEncoder
+}
+
+// syslogEncoder writes log entries as RFC5424 or RFC3164 messages, with the
+// entries encoded as JSON in MSG.
+//
+// In RFC5424 messages, namespaces and objects with keys containing "@", e.g.
+// "request@32473", become STRUCTURED-DATA elements, whose params are the
+// fields in them.
+type syslogEncoder struct {
+	// ObjectEncoder is where fields are added, either the JSON encoder or
+	// the params of the current SD element.
+	zapcore.ObjectEncoder
+
+	cfg *SyslogEncoderConfig
+	je  zapcore.Encoder
+	sd  []sdElement
+	// current is the index of the SD element opened as a namespace, or -1.
+	current int
+	// nested is set if a namespace is open in the JSON encoder.
+	nested bool
+	msgID  string
+}
+
+// NewSyslogEncoder creates an encoder writing log entries as syslog
+// messages.
+func NewSyslogEncoder(cfg SyslogEncoderConfig) zapcore.Encoder {
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	cfg.Hostname = syslogHeaderField(cfg.Hostname, maxHostnameLen)
+	if cfg.PID == 0 {
+		cfg.PID = os.Getpid()
+	}
+	if cfg.App == "" && len(os.Args) > 0 {
+		cfg.App = filepath.Base(os.Args[0])
+	}
+	cfg.App = syslogHeaderField(cfg.App, maxAppNameLen)
+
+	cfg.EncoderConfig.LineEnding = "\n"
+	je := newJSONEncoder(cfg.EncoderConfig)
+	return &syslogEncoder{
+		ObjectEncoder: je,
+		cfg:           &cfg,
+		je:            je,
+		current:       -1,
+	}
+}
+
+func (enc *syslogEncoder) Clone() zapcore.Encoder {
+	return enc.clone()
+}
+
+func (enc *syslogEncoder) clone() *syslogEncoder {
+	clone := &syslogEncoder{
+		cfg:     enc.cfg,
+		je:      enc.je.Clone(),
+		sd:      make([]sdElement, len(enc.sd)),
+		current: enc.current,
+		nested:  enc.nested,
+		msgID:   enc.msgID,
+	}
+	for i, e := range enc.sd {
+		clone.sd[i] = sdElement{id: e.id, params: e.params.clone()}
+	}
+	clone.ObjectEncoder = clone.je
+	if clone.current >= 0 {
+		clone.ObjectEncoder = clone.sd[clone.current].params
+	}
+	return clone
+}
+
+func (enc *syslogEncoder) addSDElement(id string) *flatEncoder {
+	params := newFlatEncoder(&enc.cfg.EncoderConfig)
+	enc.sd = append(enc.sd, sdElement{id: id, params: params})
+	return params
+}
+
+// isSDID reports whether the key is an SD-ID of structured data.
+func (enc *syslogEncoder) isSDID(key string) bool {
+	return enc.cfg.Format == RFC5424Format && strings.Contains(key, "@")
+}
+
+func (enc *syslogEncoder) OpenNamespace(key string) {
+	if enc.isSDID(key) {
+		enc.ObjectEncoder = enc.addSDElement(key)
+		enc.current = len(enc.sd) - 1
+		return
+	}
+	if enc.current < 0 {
+		enc.nested = true
+	}
+	enc.ObjectEncoder.OpenNamespace(key)
+}
+
+func (enc *syslogEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	if enc.isSDID(key) {
+		return obj.MarshalLogObject(enc.addSDElement(key))
+	}
+	return enc.ObjectEncoder.AddObject(key, obj)
+}
+
+func (enc *syslogEncoder) AddString(key, val string) {
+	if enc.cfg.MsgIDKey != "" && key == enc.cfg.MsgIDKey && enc.cfg.Format == RFC5424Format && enc.current < 0 && !enc.nested {
+		enc.msgID = val
+		return
+	}
+	enc.ObjectEncoder.AddString(key, val)
+}
+
+func (enc *syslogEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+
+	msg := syslogPool.Get()
+	if enc.cfg.Format == RFC3164Format {
+		enc.appendRFC3164Header(msg, ent)
+	} else {
+		final.appendRFC5424Header(msg, ent)
+	}
+
+	json, err := final.je.EncodeEntry(ent, nil)
+	if err != nil {
+		msg.Free()
+		return nil, err
+	}
+	bs := json.Bytes()
+	if enc.cfg.Framing == zapsyslog.OctetCountingFraming {
+		// strip trailing line feed
+		bs = bs[:len(bs)-1]
+	}
+	msg.Write(bs)
+	json.Free()
+
+	if enc.cfg.Framing != zapsyslog.OctetCountingFraming {
+		return msg, nil
+	}
+
+	// SYSLOG-FRAME = MSG-LEN SP SYSLOG-MSG
+	out := syslogPool.Get()
+	out.AppendInt(int64(msg.Len()))
+	out.AppendByte(' ')
+	out.Write(msg.Bytes())
+	msg.Free()
+	return out, nil
+}
+
+// appendRFC5424Header appends the header and structured data of RFC5424
+// messages, followed by the BOM of MSG.
+func (enc *syslogEncoder) appendRFC5424Header(msg *buffer.Buffer, ent zapcore.Entry) {
+	// <PRI>VERSION
+	msg.AppendByte('<')
+	msg.AppendInt(int64(enc.priority(ent.Level)))
+	msg.AppendString(">1 ")
+
+	// TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID
+	if ent.Time.IsZero() {
+		msg.AppendString(syslogNilValue)
+	} else {
+		msg.AppendString(ent.Time.Format(syslogTimestampFormat))
+	}
+	msg.AppendByte(' ')
+	msg.AppendString(enc.cfg.Hostname)
+	msg.AppendByte(' ')
+	msg.AppendString(enc.cfg.App)
+	msg.AppendByte(' ')
+	msg.AppendInt(int64(enc.cfg.PID))
+	msg.AppendByte(' ')
+	msgID := enc.msgID
+	if msgID == "" {
+		msgID = ent.LoggerName
+	}
+	msg.AppendString(syslogHeaderField(msgID, maxMsgIDLen))
+
+	// SP STRUCTURED-DATA
+	msg.AppendByte(' ')
+	if len(enc.sd) == 0 {
+		msg.AppendString(syslogNilValue)
+	}
+	for _, e := range enc.sd {
+		msg.AppendByte('[')
+		msg.AppendString(sdName(e.id))
+		for _, f := range e.params.fields {
+			msg.AppendByte(' ')
+			msg.AppendString(sdName(f.Key))
+			msg.AppendString(`="`)
+			appendSDParamValue(msg, formatFlatValue(f.Value))
+			msg.AppendByte('"')
+		}
+		msg.AppendByte(']')
+	}
+
+	// SP BOM
+	msg.AppendString(" \xef\xbb\xbf")
+}
+
+// appendRFC3164Header appends the header of RFC3164 messages,
+// "<PRI>Mmm dd hh:mm:ss host tag[pid]: ".
+func (enc *syslogEncoder) appendRFC3164Header(msg *buffer.Buffer, ent zapcore.Entry) {
+	msg.AppendByte('<')
+	msg.AppendInt(int64(enc.priority(ent.Level)))
+	msg.AppendByte('>')
+	msg.AppendString(ent.Time.Format(time.Stamp))
+	msg.AppendByte(' ')
+	msg.AppendString(enc.cfg.Hostname)
+	msg.AppendByte(' ')
+	tag := enc.cfg.App
+	if len(tag) > maxTagLen {
+		tag = tag[:maxTagLen]
+	}
+	msg.AppendString(tag)
+	msg.AppendByte('[')
+	msg.AppendInt(int64(enc.cfg.PID))
+	msg.AppendString("]: ")
+}
+
+func (enc *syslogEncoder) priority(l zapcore.Level) int {
+	return int(enc.cfg.Facility&0xf8) | syslogSeverity(l)
+}
+
+// syslogHeaderField returns the value as a header field of at most max
+// printable ASCII characters, or the nil value if empty.
+func syslogHeaderField(s string, max int) string {
+	if s == "" {
+		return syslogNilValue
+	}
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// sdName returns the name as an SD-ID or PARAM-NAME, which consists of at
+// most 32 printable ASCII characters except '=', ' ', ']' and '"'.
+func sdName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > maxSDNameLen {
+		s = s[:maxSDNameLen]
+	}
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// appendSDParamValue appends the PARAM-VALUE, escaping '"', '\' and ']'.
+func appendSDParamValue(buf *buffer.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf.AppendByte('\\')
+			buf.AppendByte(c)
+		default:
+			buf.AppendByte(c)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/imperfectgo/zap-syslog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslogEncoder(t *testing.T) {
+	cfg := defaultSyslogEncoderConfig
+	cfg.Hostname = "web1"
+	cfg.PID = 42
+	cfg.App = "test"
+	cfg.MsgIDKey = "event"
+	enc := NewSyslogEncoder(cfg)
+	enc.AddString("id", "abc")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2018, 1, 2, 3, 4, 5, 123456789, time.UTC),
+		LoggerName: "main",
+		Message:    "hello",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.String("event", "login"),
+		zap.Object("origin@32473", logfmtUser{Name: `"x]\`, Tags: []string{"a"}}),
+		zap.Int("n", 1),
+		zap.Namespace("request@32473"),
+		zap.String("method", "GET"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `<132>1 2018-01-02T03:04:05.123456Z web1 test 42 login `+
+		`[origin@32473 name="\"x\]\\" tags.0="a"][request@32473 method="GET"] `+
+		"\xef\xbb\xbf"+`{"level":"warn","ts":1514862245.1234567,"logger":"main","msg":"hello","id":"abc","n":1}`+"\n",
+		buf.String())
+}
+
+func TestSyslogEncoderDefaults(t *testing.T) {
+	cfg := defaultSyslogEncoderConfig
+	cfg.Framing = zapsyslog.OctetCountingFraming
+	cfg.TimeKey = ""
+	enc := NewSyslogEncoder(cfg)
+
+	hostname, _ := os.Hostname()
+	// without MsgIDKey, a field with an empty key isn't the MSGID
+	buf, err := enc.EncodeEntry(zapcore.Entry{LoggerName: "main.db", Message: "hello"}, []zapcore.Field{zap.String("", "x")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	msg := "<134>1 - " + syslogHeaderField(hostname, maxHostnameLen) + " " +
+		syslogHeaderField(filepath.Base(os.Args[0]), maxAppNameLen) + " " +
+		strconv.Itoa(os.Getpid()) + " main.db - \xef\xbb\xbf" +
+		`{"level":"info","logger":"main.db","msg":"hello","":"x"}`
+	assert.Equal(t, strconv.Itoa(len(msg))+" "+msg, buf.String())
+}
+
+func TestSyslogEncoderRFC3164(t *testing.T) {
+	cfg := defaultSyslogEncoderConfig
+	cfg.Format = RFC3164Format
+	cfg.Hostname = "web1"
+	cfg.PID = 42
+	cfg.App = "test"
+	cfg.TimeKey = ""
+	enc := NewSyslogEncoder(cfg)
+
+	ent := zapcore.Entry{
+		Level:   zapcore.ErrorLevel,
+		Time:    time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "hello",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Namespace("request@32473"), zap.String("method", "GET")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `<131>Jan  2 03:04:05 web1 test[42]: {"level":"error","msg":"hello","request@32473":{"method":"GET"}}`+"\n", buf.String())
+}
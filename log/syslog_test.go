@@ -0,0 +1,245 @@
+package log
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeDialer dials net.Pipe connections, the server ends are sent to conns.
+// Dials are signalled on dialing as they start, then wait for release, and
+// fail while failures is positive.
+type pipeDialer struct {
+	conns    chan net.Conn
+	dialing  chan struct{}
+	release  chan struct{}
+	failures int32
+}
+
+func newPipeDialer(failures int32, released bool) *pipeDialer {
+	d := &pipeDialer{
+		conns:    make(chan net.Conn, 10),
+		dialing:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		failures: failures,
+	}
+	if released {
+		close(d.release)
+	}
+	return d
+}
+
+func (d *pipeDialer) dial(network, raddr string) (net.Conn, error) {
+	select {
+	case d.dialing <- struct{}{}:
+	default:
+	}
+	<-d.release
+	if atomic.AddInt32(&d.failures, -1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+	client, server := net.Pipe()
+	d.conns <- server
+	return client, nil
+}
+
+func readPipeMessages(t *testing.T, conn net.Conn, n int) []string {
+	var messages []string
+	b := make([]byte, 64)
+	for i := 0; i < n; i++ {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		m, err := conn.Read(b)
+		if !assert.NoError(t, err) {
+			break
+		}
+		messages = append(messages, string(b[:m]))
+	}
+	return messages
+}
+
+func TestConnSyncerReconnect(t *testing.T) {
+	d := newPipeDialer(2, true)
+	stats := &loggerStats{}
+	s := newDialConnSyncer("tcp", "syslog", d.dial, defaultBufferConfig, stats)
+	defer s.Close()
+
+	s.Write([]byte("a"))
+	s.Write([]byte("b"))
+	server := <-d.conns
+	assert.Equal(t, []string{"a", "b"}, readPipeMessages(t, server, 2))
+	assert.Equal(t, uint64(0), stats.snapshot().Reconnects)
+
+	server.Close()
+	s.Write([]byte("c"))
+	server = <-d.conns
+	assert.Equal(t, []string{"c"}, readPipeMessages(t, server, 1))
+	assert.Equal(t, Stats{Reconnects: 1}, stats.snapshot())
+}
+
+func TestConnSyncerDropPolicy(t *testing.T) {
+	fixtures := []struct {
+		policy   DropPolicy
+		expected []string
+		dropped  uint64
+	}{
+		{DropOldest, []string{"a", "c", "d"}, 1},
+		{DropNewest, []string{"a", "b", "c"}, 1},
+		{Block, []string{"a", "b", "c", "d"}, 0},
+	}
+
+	for i, f := range fixtures {
+		d := newPipeDialer(0, false)
+		stats := &loggerStats{}
+		s := newDialConnSyncer("tcp", "syslog", d.dial, BufferConfig{Size: 2, DropPolicy: f.policy}, stats)
+
+		// "a" is taken by the writer, which is dialing
+		s.Write([]byte("a"))
+		<-d.dialing
+		s.Write([]byte("b"))
+		s.Write([]byte("c"))
+		written := make(chan struct{})
+		if f.policy == Block {
+			go func() {
+				s.Write([]byte("d"))
+				close(written)
+			}()
+			select {
+			case <-written:
+				t.Errorf("write not blocked, at index %d", i)
+			case <-time.After(50 * time.Millisecond):
+			}
+		} else {
+			s.Write([]byte("d"))
+			close(written)
+		}
+
+		close(d.release)
+		server := <-d.conns
+		assert.Equal(t, f.expected, readPipeMessages(t, server, len(f.expected)), "at index %d", i)
+		<-written
+		assert.Equal(t, f.dropped, stats.snapshot().Dropped, "at index %d", i)
+		s.Close()
+	}
+}
+
+func TestConnSyncerClose(t *testing.T) {
+	d := newPipeDialer(1000, true)
+	stats := &loggerStats{}
+	s := newDialConnSyncer("tcp", "syslog", d.dial, defaultBufferConfig, stats)
+
+	s.Write([]byte("a"))
+	s.Write([]byte("b"))
+	// Sync doesn't wait while disconnected, the writer is known to be once
+	// it dials again
+	<-d.dialing
+	<-d.dialing
+	assert.NoError(t, s.Sync())
+
+	assert.NoError(t, s.Close())
+	assert.Equal(t, uint64(2), stats.snapshot().Dropped)
+	_, err := s.Write([]byte("c"))
+	assert.Equal(t, errSinkClosed, err)
+}
+
+func TestConfigOpenSyslogUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c, err := ParseConfigFromURIString("logger:syslog?outputAddress=tcp:" + addr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("lost")
+	assert.NoError(t, l.Close())
+	assert.Equal(t, uint64(1), l.Stats().Dropped)
+}
+
+func TestConfigOpenLocalSyslog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	defer func(paths []string) { localSyslogPaths = paths }(localSyslogPaths)
+	localSyslogPaths = []string{filepath.Join(dir, "missing"), path}
+
+	c, err := ParseConfigFromURIString("logger:syslog?format=rfc3164&app=test&pid=42&hostname=web1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	l.Info("hello")
+
+	b := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(b)
+	if !assert.NoError(t, err) {
+		return
+	}
+	msg := string(b[:n])
+	assert.True(t, strings.HasPrefix(msg, "<134>"), msg)
+	assert.Contains(t, msg, " web1 test[42]: {")
+	assert.Contains(t, msg, `"msg":"hello"`)
+
+	localSyslogPaths = []string{filepath.Join(dir, "missing")}
+	_, err = c.Open()
+	assert.Error(t, err)
+}
+
+func TestConfigOpenSyslogOutputPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.log")
+	c, err := ParseConfigFromURIString("logger:syslog?outputPath=" + path + "&errorOutputPath=" + path + "&app=test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{path}, c.OutputPaths)
+	assert.Equal(t, []string{path}, c.ErrorOutputPaths)
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Info("hello")
+	assert.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(string(b), "<134>1 "), string(b))
+	assert.Contains(t, string(b), " test ")
+}
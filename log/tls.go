@@ -0,0 +1,103 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// TLSConfig configures the TLS connections of syslog outputs, i.e. the
+// output addresses with the "tls" network.
+type TLSConfig struct {
+	// CA is the path of the PEM encoded certificates to verify the server
+	// with, the system pool is used if empty.
+	CA string `json:"ca,omitempty" yaml:"ca,omitempty"`
+	// Cert and Key are the paths of the PEM encoded client certificate and
+	// its private key, for mutual authentication.
+	Cert string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Key  string `json:"key,omitempty" yaml:"key,omitempty"`
+	// ServerName overrides the name of the server to verify, which is the
+	// host of the address by default.
+	ServerName         string `json:"serverName,omitempty" yaml:"serverName,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// build loads the certificates into a tls.Config.
+func (c TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CA != "" {
+		pem, err := ioutil.ReadFile(c.CA)
+		if err != nil {
+			return nil, errors.WithMessage(err, "config: error loading tlsCA")
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: no certificate found in tlsCA %s", c.CA)
+		}
+	}
+
+	if c.Cert != "" || c.Key != "" {
+		if c.Cert == "" || c.Key == "" {
+			return nil, errors.New("config: tlsCert and tlsKey must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, errors.WithMessage(err, "config: error loading tlsCert and tlsKey")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// set sets the setting by its name in logger URIs, it returns false if the
+// name is unknown.
+func (c *TLSConfig) set(k, v string) (bool, error) {
+	switch k {
+	case "tlsCA":
+		c.CA = v
+	case "tlsCert":
+		c.Cert = v
+	case "tlsKey":
+		c.Key = v
+	case "tlsServerName":
+		c.ServerName = v
+	case "tlsInsecureSkipVerify":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return true, err
+		}
+		c.InsecureSkipVerify = b
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// addValues adds the settings to the values of logger URIs, empty settings
+// are omitted.
+func (c TLSConfig) addValues(values url.Values) {
+	m := map[string]string{
+		"tlsCA":         c.CA,
+		"tlsCert":       c.Cert,
+		"tlsKey":        c.Key,
+		"tlsServerName": c.ServerName,
+	}
+	for k, v := range m {
+		if v != "" {
+			values.Set(k, v)
+		}
+	}
+	if c.InsecureSkipVerify {
+		values.Set("tlsInsecureSkipVerify", "true")
+	}
+}
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generateTestCert writes a self-signed certificate for localhost and its
+// key into the directory, it returns their paths.
+func generateTestCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	assert.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath
+}
+
+func TestConfigOpenSyslogTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	certPath, keyPath := generateTestCert(t, dir)
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// octet-counting framing: MSG-LEN SP SYSLOG-MSG
+		r := bufio.NewReader(conn)
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return
+		}
+		msg := make([]byte, n)
+		if _, err := r.Read(msg); err != nil {
+			return
+		}
+		received <- string(msg)
+	}()
+
+	// the plain TCP output keeps the non-transparent framing
+	plainLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer plainLn.Close()
+
+	plainReceived := make(chan string, 1)
+	go func() {
+		conn, err := plainLn.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		plainReceived <- line
+	}()
+
+	c, err := ParseConfigFromURIString("logger:syslog?framing=non-transparent&app=test&tlsServerName=localhost&tlsCA=" + certPath +
+		"&outputAddress=tls:" + ln.Addr().String() + "&outputAddress=tcp:" + plainLn.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, TLSConfig{CA: certPath, ServerName: "localhost"}, c.TLS)
+
+	l, err := c.Open()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	l.Info("over tls")
+
+	select {
+	case msg := <-received:
+		assert.True(t, strings.HasPrefix(msg, "<134>1 "), msg)
+		assert.Contains(t, msg, " test ")
+		assert.True(t, strings.HasSuffix(msg, `"msg":"over tls"}`), msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	select {
+	case line := <-plainReceived:
+		assert.True(t, strings.HasPrefix(line, "<134>1 "), line)
+		assert.True(t, strings.HasSuffix(line, `"msg":"over tls"}`+"\n"), line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+}
+
+func TestTLSConfigBuild(t *testing.T) {
+	_, err := TLSConfig{Cert: "cert.pem"}.build()
+	assert.Error(t, err)
+
+	_, err = TLSConfig{CA: "/nonexistent/ca.pem"}.build()
+	assert.Error(t, err)
+
+	config, err := TLSConfig{ServerName: "logs", InsecureSkipVerify: true}.build()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "logs", config.ServerName)
+		assert.True(t, config.InsecureSkipVerify)
+	}
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TraceparentHeader is the HTTP header of W3C trace context.
+const TraceparentHeader = "traceparent"
+
+type traceparentKey struct{}
+
+// Traceparent is the W3C trace context of a request
+// (https://www.w3.org/TR/trace-context/).
+type Traceparent struct {
+	// TraceID and SpanID are lowercase hex strings, of 32 and 16 characters.
+	TraceID string
+	SpanID  string
+	Flags   byte
+}
+
+// Sampled reports whether the caller may have recorded the trace.
+func (p Traceparent) Sampled() bool {
+	return p.Flags&0x01 != 0
+}
+
+// String returns the traceparent header value.
+func (p Traceparent) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", p.TraceID, p.SpanID, p.Flags)
+}
+
+// ParseTraceparent parses the value of a traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(s string) (Traceparent, error) {
+	var p Traceparent
+	s = strings.TrimSpace(s)
+	// version-traceid-parentid-flags, future versions may append fields
+	if len(s) < 55 || len(s) > 55 && s[55] != '-' {
+		return p, fmt.Errorf("invalid traceparent: %q", s)
+	}
+	parts := strings.Split(s[:55], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return p, fmt.Errorf("invalid traceparent: %q", s)
+	}
+	for _, part := range parts {
+		if !isLowerHex(part) {
+			return p, fmt.Errorf("invalid traceparent: %q", s)
+		}
+	}
+	if parts[0] == "ff" || parts[0] == "00" && len(s) != 55 {
+		return p, fmt.Errorf("invalid traceparent version: %q", s)
+	}
+	if isZeroHex(parts[1]) || isZeroHex(parts[2]) {
+		return p, fmt.Errorf("invalid traceparent ids: %q", s)
+	}
+
+	flags, _ := hex.DecodeString(parts[3])
+	p.TraceID = parts[1]
+	p.SpanID = parts[2]
+	p.Flags = flags[0]
+	return p, nil
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroHex(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// WithTraceparent returns a copy of ctx carrying the trace context parsed from
+// the value of a traceparent header, which is extracted by
+// TraceparentExtractor. Invalid values are ignored, as required by the spec.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	p, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceparentKey{}, p)
+}
+
+// TraceparentFromContext returns the trace context carried by ctx.
+func TraceparentFromContext(ctx context.Context) (Traceparent, bool) {
+	p, ok := ctx.Value(traceparentKey{}).(Traceparent)
+	return p, ok
+}
+
+// TraceparentExtractor is a ContextExtractor of the trace context set by
+// WithTraceparent, it adds the "trace_id", "span_id" and "trace_flags"
+// fields.
+func TraceparentExtractor(ctx context.Context) []zapcore.Field {
+	p, ok := TraceparentFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.String("trace_id", p.TraceID),
+		zap.String("span_id", p.SpanID),
+		zap.String("trace_flags", fmt.Sprintf("%02x", p.Flags)),
+	}
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	p, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", p.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", p.SpanID)
+		assert.True(t, p.Sampled())
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", p.String())
+	}
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err)
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736+00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceparent(s)
+		assert.Error(t, err, "traceparent %q", s)
+	}
+}
+
+func TestTraceparentExtractor(t *testing.T) {
+	prev, _ := extractors.Load().([]ContextExtractor)
+	defer extractors.Store(prev)
+	RegisterContextExtractor(TraceparentExtractor)
+
+	var buf bytes.Buffer
+	encoderCfg := defaultJSONEncoderConfig
+	encoderCfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	defer ReplaceGlobals(zap.New(core))()
+
+	ctx := WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	InfoCtx(ctx, "a", zap.Int("n", 1))
+	Ctx(WithFields(ctx, zap.String("user", "jane"))).Info("b")
+	InfoCtx(WithTraceparent(context.Background(), "invalid"), "c")
+
+	assert.Equal(t, `{"level":"info","msg":"a","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01","n":1}
+{"level":"info","msg":"b","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01","user":"jane"}
+{"level":"info","msg":"c"}
+`, buf.String())
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// watchConfigInterval is the interval to poll the watched config files at.
+var watchConfigInterval = 2 * time.Second
+
+// WatchConfig builds the global logger from a YAML or JSON config file, like
+// the log.format flag with "@path", and rebuilds it whenever the file
+// changes, so the outputs, encoders and levels of a running application can
+// be changed without restarting it. The file is polled for changes.
+//
+// A new logger replaces the globals only after it's built successfully, then
+// the sinks of the previous one are synced, and closed after a grace period
+// for the goroutines still logging to it, and the changes are logged. If the
+// file is broken, the error is logged once and the previous logger stays in
+// place.
+//
+// It returns an error if the file can't be loaded at first, and a function to
+// stop watching otherwise.
+func WatchConfig(path string) (stop func(), err error) {
+	w := &configWatcher{
+		path:     path,
+		interval: watchConfigInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return func() {
+		close(w.stop)
+		<-w.done
+	}, nil
+}
+
+type configWatcher struct {
+	path     string
+	interval time.Duration
+	// data is the content of the file the current logger was built from.
+	data    []byte
+	configs Configs
+	// failed is the content of the file that failed to build last time.
+	failed []byte
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (w *configWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.reload(); err != nil {
+				L().Error("error reloading log config", zap.String("path", w.path), zap.Error(err))
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reload rebuilds the global logger if the file changed since the last time.
+func (w *configWatcher) reload() error {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	if w.data != nil && bytes.Equal(data, w.data) {
+		return nil
+	}
+	// the error of a broken file is reported once, until it changes again
+	if w.failed != nil && bytes.Equal(data, w.failed) {
+		return nil
+	}
+
+	l, cs, level, err := w.open(data)
+	if err != nil {
+		w.failed = data
+		return err
+	}
+
+	fromLevel := baseLoggerLevel.Level()
+	prev := w.configs
+	w.data = data
+	w.failed = nil
+	w.configs = cs
+	replaceOwnedGlobals(l)
+	if level != nil {
+		baseLoggerLevel.SetLevel(*level)
+	}
+
+	if prev != nil {
+		added, removed := diffConfigs(prev, cs)
+		L().Info("log config reloaded",
+			zap.String("path", w.path),
+			zap.Strings("added", added),
+			zap.Strings("removed", removed),
+			zap.Stringer("fromLevel", fromLevel),
+			zap.Stringer("toLevel", baseLoggerLevel.Level()),
+		)
+	}
+	return nil
+}
+
+// open builds a logger from the content of the file, it returns the level
+// set by the file, if any, see installFileLevels.
+func (w *configWatcher) open(data []byte) (*Logger, Configs, *zapcore.Level, error) {
+	cs, err := decodeConfigsFile(w.path, data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	level := installFileLevels(cs)
+	l, err := cs.Open()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return l, cs, level, nil
+}
+
+// diffConfigs returns the URIs of the targets added to and removed from the
+// configs.
+func diffConfigs(from, to Configs) (added, removed []string) {
+	oldURIs := configURIs(from)
+	newURIs := configURIs(to)
+	for uri := range newURIs {
+		if !oldURIs[uri] {
+			added = append(added, uri)
+		}
+	}
+	for uri := range oldURIs {
+		if !newURIs[uri] {
+			removed = append(removed, uri)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func configURIs(cs Configs) map[string]bool {
+	uris := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		u, err := c.URI()
+		if err != nil {
+			// the configs are built already, there is no invalid one
+			continue
+		}
+		uris[u.String()] = true
+	}
+	return uris
+}
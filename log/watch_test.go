@@ -0,0 +1,83 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	defer initGlobalLogger(defaultLogFormatURI)
+	defer baseLoggerLevel.SetLevel(baseLoggerLevel.Level())
+	defer func(interval time.Duration) { watchConfigInterval = interval }(watchConfigInterval)
+	watchConfigInterval = 10 * time.Millisecond
+
+	path := filepath.Join(dir, "logging.yaml")
+	out1 := filepath.Join(dir, "out1.log")
+	out2 := filepath.Join(dir, "out2.log")
+	writeConfig := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	readOutput := func(path string) string {
+		b, _ := ioutil.ReadFile(path)
+		return string(b)
+	}
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 200; i++ {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	_, err = WatchConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+
+	writeConfig("encoderType: logfmt\nlevel: info\noutputPaths: [" + out1 + "]\n")
+	stop, err := WatchConfig(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stop()
+
+	L().Info("a")
+	assert.Contains(t, readOutput(out1), "msg=a")
+
+	// broken configs leave the logger in place
+	writeConfig("encoderType: logfmt\nlevel: debug\noutputPaths: [" + out2 + "]\nunknown: 1\n")
+	assert.True(t, waitFor(func() bool {
+		return strings.Contains(readOutput(out1), "error reloading log config")
+	}))
+	assert.Equal(t, "info", baseLoggerLevel.Level().String())
+	// the error is logged once until the file changes
+	time.Sleep(5 * watchConfigInterval)
+	assert.Equal(t, 1, strings.Count(readOutput(out1), "error reloading log config"))
+
+	prevL := L()
+	writeConfig("encoderType: logfmt\nlevel: debug\noutputPaths: [" + out2 + "]\n")
+	assert.True(t, waitFor(func() bool {
+		return strings.Contains(readOutput(out2), "log config reloaded")
+	}))
+	assert.Equal(t, "debug", baseLoggerLevel.Level().String())
+	assert.Contains(t, readOutput(out2), "fromLevel=info toLevel=debug")
+	// the replaced logger is still open for a while
+	prevL.Info("late")
+	assert.Contains(t, readOutput(out1), "msg=late")
+
+	L().Debug("b")
+	assert.Contains(t, readOutput(out2), "msg=b")
+	assert.NotContains(t, readOutput(out1), "msg=b")
+}